@@ -1,6 +1,7 @@
 package sap
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -163,3 +164,113 @@ Please let me know if you need any modifications to the output format.`
 		}
 	}
 }
+
+type BenchWideRecord struct {
+	ID          string   `json:"id"`
+	FirstName   string   `json:"first_name"`
+	LastName    string   `json:"last_name"`
+	Email       string   `json:"email"`
+	Phone       string   `json:"phone,omitempty"`
+	Age         int      `json:"age"`
+	Score       float64  `json:"score"`
+	Active      bool     `json:"active"`
+	Role        string   `json:"role" gsap:"enum=admin|member|guest"`
+	Country     string   `json:"country" gsap:"default=US"`
+	Tags        []string `json:"tags"`
+	Notes       *string  `json:"notes,omitempty"`
+	CompanyName string
+	Department  string
+	Title       string
+	BenchContributor
+}
+
+// benchWideInput is already decoded so that only coercion is measured. The
+// last four keys differ in case from the field names.
+var benchWideInput = map[string]interface{}{
+	"id":          "u-1001",
+	"first_name":  "Alice",
+	"last_name":   "Johnson",
+	"email":       "alice@example.com",
+	"phone":       "555-0100",
+	"age":         30.0,
+	"score":       97.5,
+	"active":      true,
+	"role":        "Admin",
+	"tags":        []interface{}{"go", "json"},
+	"notes":       "none",
+	"companyname": "Acme",
+	"DEPARTMENT":  "Engineering",
+	"title":       "Staff Engineer",
+	"name":        "Alice",
+	"commits":     142.0,
+}
+
+// forgetStructPlans empties the struct plan cache, so the next coercion
+// of each type compiles its plan again as it did before plans were cached.
+func forgetStructPlans() {
+	structPlans.Range(func(key, _ interface{}) bool {
+		structPlans.Delete(key)
+		return true
+	})
+}
+
+// BenchmarkCoerceWideStruct compares coercion with cached struct plans
+// against compiling them on every call.
+func BenchmarkCoerceWideStruct(b *testing.B) {
+	targetType := reflect.TypeOf(BenchWideRecord{})
+
+	for _, bc := range []struct {
+		name   string
+		cached bool
+	}{
+		{"cached", true},
+		{"uncached", false},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if !bc.cached {
+					forgetStructPlans()
+				}
+				if _, _, err := NewTypeCoercer().Coerce(benchWideInput, targetType); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkParseWideStructParallel compares parsing from many goroutines
+// with cached struct plans against compiling them on every call.
+func BenchmarkParseWideStructParallel(b *testing.B) {
+	input := `{"id": "u-1001", "first_name": "Alice", "last_name": "Johnson",
+		"email": "alice@example.com", "age": 30, "score": 97.5, "active": true,
+		"role": "admin", "tags": ["go", "json"], "companyname": "Acme",
+		"DEPARTMENT": "Engineering", "title": "Staff Engineer", "name": "Alice",
+		"commits": 142}`
+
+	for _, bc := range []struct {
+		name   string
+		cached bool
+	}{
+		{"cached", true},
+		{"uncached", false},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if !bc.cached {
+						forgetStructPlans()
+					}
+					if _, err := Parse[BenchWideRecord](input); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
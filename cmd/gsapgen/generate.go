@@ -0,0 +1,376 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// sapImportPath is the import path of the runtime used by generated code.
+const sapImportPath = "github.com/carpcarp/gsap"
+
+// pkgInfo holds the type declarations of the package being generated for.
+type pkgInfo struct {
+	name  string
+	types map[string]*ast.TypeSpec
+}
+
+// loadPackage parses the non-test Go files in dir.
+func loadPackage(dir string) (*pkgInfo, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	pkg := &pkgInfo{types: make(map[string]*ast.TypeSpec)}
+	fset := token.NewFileSet()
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		file, err := parser.ParseFile(fset, path, src, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		if pkg.name == "" {
+			pkg.name = file.Name.Name
+		}
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				ts := spec.(*ast.TypeSpec)
+				pkg.types[ts.Name.Name] = ts
+			}
+		}
+	}
+	if pkg.name == "" {
+		return nil, fmt.Errorf("no Go files in %s", dir)
+	}
+	return pkg, nil
+}
+
+// genField is a field set by generated code.
+type genField struct {
+	selector string   // Go selector from the struct, e.g. "Contributor.Name"
+	typ      ast.Expr // declared type
+	setter   string   // sap function that sets the field, e.g. "FieldString"
+	fields   string   // for FieldStruct, the generated function for the field's type
+}
+
+// Set returns the statement that sets field i of v.
+func (f genField) Set(i int) string {
+	if f.fields != "" {
+		return fmt.Sprintf("sap.%s(st, %d, &v.%s, %s)", f.setter, i, f.selector, f.fields)
+	}
+	return fmt.Sprintf("sap.%s(st, %d, &v.%s)", f.setter, i, f.selector)
+}
+
+// genType is a struct type to generate a coercer for.
+type genType struct {
+	Name   string
+	Fields []genField
+}
+
+// FuncName returns the name of the exported coercer, e.g. CoerceUser.
+func (t genType) FuncName() string {
+	return "Coerce" + strings.ToUpper(t.Name[:1]) + t.Name[1:]
+}
+
+// FieldsFuncName returns the name of the function that sets the fields.
+func (t genType) FieldsFuncName() string {
+	return fieldsFuncName(t.Name)
+}
+
+// fieldsFuncName returns the name of the function that sets the fields of
+// type name, e.g. coerceUserFields.
+func fieldsFuncName(name string) string {
+	return "coerce" + strings.ToUpper(name[:1]) + name[1:] + "Fields"
+}
+
+// Selectors returns the selector of each field, in order.
+func (t genType) Selectors() []string {
+	s := make([]string, len(t.Fields))
+	for i, f := range t.Fields {
+		s[i] = f.selector
+	}
+	return s
+}
+
+var fileTemplate = template.Must(template.New("file").Parse(`// Code generated by gsapgen -type={{.TypeList}}; DO NOT EDIT.
+
+package {{.Package}}
+
+import sap "{{.Import}}"
+
+func init() {
+{{- range .Types}}
+	sap.RegisterGenerated({{.FieldsFuncName}},
+	{{- range .Selectors}}
+		"{{.}}",
+	{{- end}}
+	)
+{{- end}}
+}
+{{range .Types}}
+// {{.FuncName}} converts raw, a value decoded from JSON, to {{.Name}} with
+// the same rules as sap.Parse, adding the flags for any coercion to s.
+func {{.FuncName}}(raw interface{}, s *sap.Score) ({{.Name}}, error) {
+	return sap.CoerceGenerated(raw, s, {{.FieldsFuncName}})
+}
+
+func {{.FieldsFuncName}}(st *sap.StructState) (v {{.Name}}) {
+{{- range $i, $f := .Fields}}
+	{{$f.Set $i}}
+{{- end}}
+	return v
+}
+{{end}}`))
+
+// generate returns the source of a file with coercers for typeNames.
+func generate(pkg *pkgInfo, typeNames []string) ([]byte, error) {
+	for i := range typeNames {
+		typeNames[i] = strings.TrimSpace(typeNames[i])
+	}
+	var types []genType
+	for _, name := range typeNames {
+		spec, ok := pkg.types[name]
+		if !ok {
+			return nil, fmt.Errorf("type %s not found in package %s", name, pkg.name)
+		}
+		if spec.TypeParams != nil {
+			return nil, fmt.Errorf("%s: generic types are not supported", name)
+		}
+		st, ok := pkg.structOf(spec.Type)
+		if !ok {
+			return nil, fmt.Errorf("%s is not a struct type", name)
+		}
+
+		fields, err := pkg.flatten(st, name, "")
+		if err != nil {
+			return nil, err
+		}
+		seen := map[string]bool{name: true}
+		for _, f := range fields {
+			if err := pkg.check(f.typ, name+"."+f.selector, seen); err != nil {
+				return nil, err
+			}
+		}
+		for i := range fields {
+			fields[i].setter, fields[i].fields = pkg.setter(fields[i].typ, typeNames)
+		}
+		types = append(types, genType{Name: name, Fields: fields})
+	}
+
+	var buf bytes.Buffer
+	err := fileTemplate.Execute(&buf, map[string]interface{}{
+		"TypeList": strings.Join(typeNames, ","),
+		"Package":  pkg.name,
+		"Import":   sapImportPath,
+		"Types":    types,
+	})
+	if err != nil {
+		return nil, err
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w", err)
+	}
+	return src, nil
+}
+
+// structOf returns the struct type expr denotes, following named types
+// declared in the package.
+func (p *pkgInfo) structOf(expr ast.Expr) (*ast.StructType, bool) {
+	for i := 0; i < len(p.types); i++ {
+		switch e := expr.(type) {
+		case *ast.StructType:
+			return e, true
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.Ident:
+			spec, ok := p.types[e.Name]
+			if !ok || spec.TypeParams != nil {
+				return nil, false
+			}
+			expr = spec.Type
+		default:
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// setter returns the sap function that sets a field of type expr, and for
+// FieldStruct the generated function for its type. Fields of basic kinds,
+// and of the generated struct types, convert matching JSON values without
+// reflection; everything else goes through sap.Field.
+func (p *pkgInfo) setter(expr ast.Expr, generated []string) (setter, fields string) {
+	if e, ok := expr.(*ast.Ident); ok {
+		for _, name := range generated {
+			if name == e.Name {
+				return "FieldStruct", fieldsFuncName(name)
+			}
+		}
+	}
+
+	// A named type takes the setter of its underlying type; at run time,
+	// enums and custom coercers registered for it still take precedence
+	for i := 0; i <= len(p.types); i++ {
+		switch e := expr.(type) {
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.Ident:
+			switch e.Name {
+			case "string":
+				return "FieldString", ""
+			case "bool":
+				return "FieldBool", ""
+			case "int", "int8", "int16", "int32", "int64", "rune":
+				return "FieldInt", ""
+			case "uint", "uint8", "uint16", "uint32", "uint64", "byte":
+				return "FieldUint", ""
+			case "float32", "float64":
+				return "FieldFloat", ""
+			}
+			spec, ok := p.types[e.Name]
+			if !ok || spec.TypeParams != nil {
+				return "Field", ""
+			}
+			expr = spec.Type
+		default:
+			return "Field", ""
+		}
+	}
+	return "Field", ""
+}
+
+// flatten lists the exported fields of st in declaration order, with the
+// fields of embedded structs in place of the embedded field, as the sap
+// runtime does.
+func (p *pkgInfo) flatten(st *ast.StructType, typeName, prefix string) ([]genField, error) {
+	var fields []genField
+	for _, f := range st.Fields.List {
+		if len(f.Names) > 0 {
+			for _, n := range f.Names {
+				if ast.IsExported(n.Name) {
+					fields = append(fields, genField{selector: prefix + n.Name, typ: f.Type})
+				}
+			}
+			continue
+		}
+
+		// Embedded field: structs are flattened, pointers and other types
+		// are ordinary fields named after their type
+		name, embedded := embeddedName(f.Type)
+		switch e := embedded.(type) {
+		case *ast.Ident:
+			if sub, ok := p.structOf(e); ok {
+				nested, err := p.flatten(sub, typeName, prefix+name+".")
+				if err != nil {
+					return nil, err
+				}
+				fields = append(fields, nested...)
+				continue
+			}
+		case *ast.SelectorExpr:
+			return nil, fmt.Errorf("%s: cannot see the fields of embedded %s.%s from another package; embed a pointer or give the field a name", typeName, e.X, e.Sel.Name)
+		case *ast.IndexExpr, *ast.IndexListExpr:
+			return nil, fmt.Errorf("%s: embedded generic type %s is not supported", typeName, name)
+		}
+		if ast.IsExported(name) {
+			fields = append(fields, genField{selector: prefix + name, typ: f.Type})
+		}
+	}
+	return fields, nil
+}
+
+// embeddedName returns the field name of an embedded type and, for a
+// non-pointer, the type expression itself.
+func embeddedName(expr ast.Expr) (string, ast.Expr) {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e.Name, e
+	case *ast.SelectorExpr:
+		return e.Sel.Name, e
+	case *ast.StarExpr:
+		name, _ := embeddedName(e.X)
+		return name, nil
+	case *ast.IndexExpr:
+		name, _ := embeddedName(e.X)
+		return name, e
+	case *ast.IndexListExpr:
+		name, _ := embeddedName(e.X)
+		return name, e
+	}
+	return "", nil
+}
+
+// check reports an error if values of type expr can't be coerced from
+// JSON. Types from other packages are assumed to be parseable.
+func (p *pkgInfo) check(expr ast.Expr, path string, seen map[string]bool) error {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		switch e.Name {
+		case "complex64", "complex128", "uintptr":
+			return fmt.Errorf("%s: %s cannot be parsed from JSON", path, e.Name)
+		}
+		spec, ok := p.types[e.Name]
+		if !ok || seen[e.Name] {
+			return nil
+		}
+		seen[e.Name] = true
+		if st, ok := spec.Type.(*ast.StructType); ok {
+			return p.checkStruct(st, path, seen)
+		}
+		return p.check(spec.Type, path, seen)
+	case *ast.ParenExpr:
+		return p.check(e.X, path, seen)
+	case *ast.StarExpr:
+		return p.check(e.X, path, seen)
+	case *ast.ArrayType:
+		return p.check(e.Elt, path+"[]", seen)
+	case *ast.MapType:
+		if err := p.check(e.Key, path+"{key}", seen); err != nil {
+			return err
+		}
+		return p.check(e.Value, path+"{}", seen)
+	case *ast.StructType:
+		return p.checkStruct(e, path, seen)
+	case *ast.ChanType:
+		return fmt.Errorf("%s: channels cannot be parsed from JSON", path)
+	case *ast.FuncType:
+		return fmt.Errorf("%s: funcs cannot be parsed from JSON", path)
+	case *ast.SelectorExpr:
+		if x, ok := e.X.(*ast.Ident); ok && x.Name == "unsafe" && e.Sel.Name == "Pointer" {
+			return fmt.Errorf("%s: unsafe.Pointer cannot be parsed from JSON", path)
+		}
+	}
+	return nil
+}
+
+// checkStruct checks the fields of a nested struct type.
+func (p *pkgInfo) checkStruct(st *ast.StructType, path string, seen map[string]bool) error {
+	fields, err := p.flatten(st, path, "")
+	if err != nil {
+		return err
+	}
+	for _, f := range fields {
+		if err := p.check(f.typ, path+"."+f.selector, seen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
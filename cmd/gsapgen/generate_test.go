@@ -0,0 +1,156 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGeneratedExampleUpToDate(t *testing.T) {
+	pkg, err := loadPackage("internal/example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := generate(pkg, []string{"Order", "Customer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile("internal/example/order_gsap.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("internal/example/order_gsap.go is stale; run go generate ./...\n%s", got)
+	}
+}
+
+// loadSource writes src to a temporary package and loads it.
+func loadSource(t *testing.T, src string) *pkgInfo {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "types.go"), []byte("package p\n\n"+src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := loadPackage(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pkg
+}
+
+func TestFlattenEmbedded(t *testing.T) {
+	pkg := loadSource(t, `
+type Base struct {
+	ID      string
+	created string
+}
+
+type named Base
+
+type Meta struct{ Source string }
+
+type T struct {
+	Base
+	*Meta
+	named
+	A, B int
+	hidden bool
+}
+`)
+	st, _ := pkg.structOf(pkg.types["T"].Type)
+	fields, err := pkg.flatten(st, "T", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range fields {
+		got = append(got, f.selector)
+	}
+	want := []string{"Base.ID", "Meta", "named.ID", "A", "B"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		wantErr string
+	}{
+		{"missing", `type T struct{}`, "type U not found"},
+		{"not a struct", `type U []string`, "U is not a struct type"},
+		{"generic", `type U[V any] struct{ X V }`, "generic types are not supported"},
+		{"chan", `type U struct{ C chan int }`, "U.C: channels cannot be parsed"},
+		{"func", `type U struct{ F func() }`, "U.F: funcs cannot be parsed"},
+		{"complex", `type U struct{ N []complex128 }`, "U.N[]: complex128 cannot be parsed"},
+		{"nested", "type Inner struct{ C chan int }\ntype U struct{ In map[string]*Inner }", "U.In{}.C: channels"},
+		{"external embedded", "import \"time\"\ntype U struct{ time.Time }", "cannot see the fields of embedded time.Time"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := generate(loadSource(t, tt.src), []string{"U"})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestGenerateRecursiveType(t *testing.T) {
+	pkg := loadSource(t, `type U struct {
+	Name     string
+	Children []U
+	Parent   *U
+}`)
+	src, err := generate(pkg, []string{"U"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(src), "sap.Field(st, 2, &v.Parent)") {
+		t.Errorf("unexpected output:\n%s", src)
+	}
+}
+
+func TestGenerateSetters(t *testing.T) {
+	pkg := loadSource(t, `type Level string
+type Code Level
+type Point struct{ X, Y float64 }
+type Alias Point
+type U struct {
+	Name  string
+	Level Code
+	Count int64
+	Size  byte
+	Ratio (float32)
+	On    bool
+	At    Point
+	Other Alias
+	Tags  []string
+	Ptr   *int
+}`)
+	src, err := generate(pkg, []string{"U", "Point"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"sap.FieldString(st, 0, &v.Name)",
+		"sap.FieldString(st, 1, &v.Level)",
+		"sap.FieldInt(st, 2, &v.Count)",
+		"sap.FieldUint(st, 3, &v.Size)",
+		"sap.FieldFloat(st, 4, &v.Ratio)",
+		"sap.FieldBool(st, 5, &v.On)",
+		"sap.FieldStruct(st, 6, &v.At, coercePointFields)",
+		"sap.Field(st, 7, &v.Other)",
+		"sap.Field(st, 8, &v.Tags)",
+		"sap.Field(st, 9, &v.Ptr)",
+		"sap.CoerceGenerated(raw, s, coerceUFields)",
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("output is missing %s:\n%s", want, src)
+		}
+	}
+}
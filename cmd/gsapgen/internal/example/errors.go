@@ -0,0 +1,5 @@
+package example
+
+import "errors"
+
+var errNoContact = errors.New("customer needs a name or email")
@@ -0,0 +1,114 @@
+package example
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	sap "github.com/carpcarp/gsap"
+)
+
+// reflectedOrder has the same fields as Order but no generated coercer.
+type reflectedOrder Order
+
+var orderInputs = []string{
+	`{"id": "A-1", "status": "sent", "items": [{"sku": "X", "quantity": "2", "Price": "$4.50"}],
+	  "total": "9", "gift": "yes", "notes": "n/a", "placed_at": "2024-03-01",
+	  "metadata": {"channel": "web"}, "customer": {"name": "Ada", "email": "ada@example.com", "tier": "2"},
+	  "source": "import"}`,
+	`{"ID": "A-2", "STATUS": "delivered", "items": [], "total": -1, "currency": "none"}`,
+	`{"status": "lost", "customer": {"email": "not-an-email"}}`,
+	`{"id": "A-4", "items": [{"sku": "Y"}], "customer": {}}`,
+	`{id: 'A-5', items: [{sku: 'Z', quantity: 1}], total: 3,}`,
+	`{"Id": "A-6", "total": 2.5, "gift": true, "currency": "N/A", "source": null,
+	  "Customer": {"Name": "Bo", "tier": 2.7, "email": "bo@example.com"}}`,
+}
+
+func TestGeneratedMatchesReflection(t *testing.T) {
+	for i, input := range orderInputs {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			genValue, gen, genErr := sap.ParseDetailed[Order](input)
+			refValue, ref, refErr := sap.ParseDetailed[reflectedOrder](input)
+
+			if fmt.Sprint(genErr) != fmt.Sprint(refErr) {
+				t.Fatalf("errors differ:\ngenerated: %v\nreflected: %v", genErr, refErr)
+			}
+			if !reflect.DeepEqual(genValue, Order(refValue)) {
+				t.Errorf("values differ:\ngenerated: %+v\nreflected: %+v", genValue, refValue)
+			}
+			if gen == nil || ref == nil {
+				return
+			}
+			if !reflect.DeepEqual(gen.Score.Flags(), ref.Score.Flags()) {
+				t.Errorf("flags differ:\ngenerated: %v\nreflected: %v", gen.Score.Flags(), ref.Score.Flags())
+			}
+			if !reflect.DeepEqual(gen.Presence, ref.Presence) {
+				t.Errorf("presence differs:\ngenerated: %v\nreflected: %v", gen.Presence, ref.Presence)
+			}
+		})
+	}
+}
+
+func TestCoerceOrder(t *testing.T) {
+	raw := map[string]interface{}{
+		"id":       "A-1",
+		"items":    []interface{}{map[string]interface{}{"sku": "X"}},
+		"customer": map[string]interface{}{"name": "Ada", "tier": "3"},
+	}
+	var score sap.Score
+	order, err := CoerceOrder(raw, &score)
+	if err != nil {
+		t.Fatalf("CoerceOrder failed: %v", err)
+	}
+	if order.ID != "A-1" || order.Currency != "USD" || order.Customer.Tier != 3 {
+		t.Errorf("unexpected order: %+v", order)
+	}
+	if score.Flags()[string(sap.FlagDefaultApplied)] == 0 {
+		t.Errorf("expected DefaultApplied flag, got %v", score.Flags())
+	}
+
+	if _, err := CoerceCustomer(map[string]interface{}{}, nil); err == nil {
+		t.Error("expected an error for a customer without a name")
+	}
+}
+
+// benchOrder is decoded once so that the benchmarks measure coercion.
+var benchOrder = map[string]interface{}{
+	"id":       "A-1",
+	"status":   "shipped",
+	"total":    9.0,
+	"gift":     true,
+	"currency": "EUR",
+	"customer": map[string]interface{}{"name": "Ada", "email": "ada@example.com", "tier": 2.0},
+	"source":   "import",
+}
+
+func TestGeneratedAllocatesLess(t *testing.T) {
+	generated := testing.AllocsPerRun(100, func() {
+		CoerceOrder(benchOrder, nil)
+	})
+	reflected := testing.AllocsPerRun(100, func() {
+		sap.Coerce[reflectedOrder](benchOrder, nil)
+	})
+	if generated >= reflected {
+		t.Errorf("generated coercion made %v allocations, reflected %v", generated, reflected)
+	}
+}
+
+func BenchmarkCoerceGenerated(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := CoerceOrder(benchOrder, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCoerceReflected(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := sap.Coerce[reflectedOrder](benchOrder, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+// Code generated by gsapgen -type=Order,Customer; DO NOT EDIT.
+
+package example
+
+import sap "github.com/carpcarp/gsap"
+
+func init() {
+	sap.RegisterGenerated(coerceOrderFields,
+		"ID",
+		"Status",
+		"Items",
+		"Total",
+		"Currency",
+		"Gift",
+		"Notes",
+		"PlacedAt",
+		"Metadata",
+		"Customer",
+		"auditInfo.Source",
+	)
+	sap.RegisterGenerated(coerceCustomerFields,
+		"Name",
+		"Email",
+		"Tier",
+	)
+}
+
+// CoerceOrder converts raw, a value decoded from JSON, to Order with
+// the same rules as sap.Parse, adding the flags for any coercion to s.
+func CoerceOrder(raw interface{}, s *sap.Score) (Order, error) {
+	return sap.CoerceGenerated(raw, s, coerceOrderFields)
+}
+
+func coerceOrderFields(st *sap.StructState) (v Order) {
+	sap.FieldString(st, 0, &v.ID)
+	sap.FieldString(st, 1, &v.Status)
+	sap.Field(st, 2, &v.Items)
+	sap.FieldFloat(st, 3, &v.Total)
+	sap.FieldString(st, 4, &v.Currency)
+	sap.FieldBool(st, 5, &v.Gift)
+	sap.Field(st, 6, &v.Notes)
+	sap.Field(st, 7, &v.PlacedAt)
+	sap.Field(st, 8, &v.Metadata)
+	sap.FieldStruct(st, 9, &v.Customer, coerceCustomerFields)
+	sap.FieldString(st, 10, &v.auditInfo.Source)
+	return v
+}
+
+// CoerceCustomer converts raw, a value decoded from JSON, to Customer with
+// the same rules as sap.Parse, adding the flags for any coercion to s.
+func CoerceCustomer(raw interface{}, s *sap.Score) (Customer, error) {
+	return sap.CoerceGenerated(raw, s, coerceCustomerFields)
+}
+
+func coerceCustomerFields(st *sap.StructState) (v Customer) {
+	sap.FieldString(st, 0, &v.Name)
+	sap.FieldString(st, 1, &v.Email)
+	sap.FieldInt(st, 2, &v.Tier)
+	return v
+}
@@ -0,0 +1,50 @@
+// Package example holds types with coercers generated by gsapgen, to check
+// that generated code behaves like the reflection-based coercion.
+package example
+
+import "time"
+
+//go:generate go run github.com/carpcarp/gsap/cmd/gsapgen -type=Order,Customer
+
+// Order exercises most field kinds and tag options.
+type Order struct {
+	ID       string            `json:"id" gsap:"required"`
+	Status   string            `json:"status" gsap:"enum=pending|shipped|delivered,alias=sent:shipped"`
+	Items    []LineItem        `json:"items" gsap:"len=1.."`
+	Total    float64           `json:"total" gsap:"min=0"`
+	Currency string            `json:"currency" gsap:"default=USD"`
+	Gift     bool              `json:"gift"`
+	Notes    *string           `json:"notes,omitempty"`
+	PlacedAt time.Time         `json:"placed_at"`
+	Metadata map[string]string `json:"metadata"`
+	Customer Customer          `json:"customer"`
+	internal string            // never set from input
+	auditInfo
+}
+
+// LineItem is coerced by reflection, since it has no generated coercer.
+type LineItem struct {
+	SKU      string `json:"sku"`
+	Quantity int    `json:"quantity"`
+	Price    float64
+}
+
+// Customer has its own generated coercer and a Validate method.
+type Customer struct {
+	Name  string `json:"name" gsap:"required"`
+	Email string `json:"email" gsap:"format=email"`
+	Tier  int    `json:"tier"`
+}
+
+// Validate rejects customers without a way to contact them.
+func (c Customer) Validate() error {
+	if c.Name == "" && c.Email == "" {
+		return errNoContact
+	}
+	return nil
+}
+
+// auditInfo is embedded unexported; its exported fields are still set.
+type auditInfo struct {
+	Source string `json:"source"`
+}
@@ -0,0 +1,81 @@
+// Command gsapgen generates coercers for struct types that set their
+// fields directly, converting the common cases without reflection.
+//
+// Given a struct type User in the current package, it writes a file with
+//
+//	func CoerceUser(raw interface{}, s *sap.Score) (User, error)
+//
+// and an init function that registers the generated code, so that
+// sap.Parse[User] and every struct containing a User use it
+// automatically. The coercion rules are the same as for any other struct:
+// key matching, tag options, defaults, constraints, presence and
+// validation all behave identically.
+//
+// Fields of basic kinds (strings, bools, integers and floats, or named
+// types based on them) and of the other generated types are converted
+// directly when the input has the matching JSON type, such as a number for
+// an int. Everything else, including any field with a custom coercer, enum
+// or enum tag, and any value that needs coercing, like "42" for an int,
+// goes through the same reflection-based code as Parse. Matching keys,
+// recording presence and checking constraints are shared with Parse too,
+// so the saving is the reflection and allocation for each direct field.
+//
+// It is meant to be run by go generate:
+//
+//	//go:generate go run github.com/carpcarp/gsap/cmd/gsapgen -type=User,Order
+//
+// The output goes to <type>_gsap.go, named after the first type, unless
+// -output is given. Generation fails for types that can't be parsed from
+// JSON, such as channels and funcs, and the generated code fails to
+// compile if a field is renamed or retyped until it is regenerated.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("gsapgen: ")
+
+	typeNames := flag.String("type", "", "comma-separated list of struct type names; required")
+	output := flag.String("output", "", "output file name; default <dir>/<type>_gsap.go")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: gsapgen -type T[,T...] [-output file] [dir]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *typeNames == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	types := strings.Split(*typeNames, ",")
+
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+
+	pkg, err := loadPackage(dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	src, err := generate(pkg, types)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	outputName := *output
+	if outputName == "" {
+		outputName = filepath.Join(dir, strings.ToLower(types[0])+"_gsap.go")
+	}
+	if err := os.WriteFile(outputName, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -1,34 +1,125 @@
 package sap
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 )
 
-// TypeCoercer handles type coercion
+// TypeCoercer handles type coercion.
+//
+// A TypeCoercer tracks the location and errors of the value being coerced,
+// so it must not be used by several goroutines at once.
 type TypeCoercer struct {
-	visited map[string]bool // Track visited types for cycle detection
+	path     []string                       // Location of the value being coerced, e.g. ["employees", "[2]", "age"]
+	pathBuf  [8]string                      // Backs path for shallow values
+	errors   []FieldError                   // Fields that failed to coerce
+	presence []presenceEntry                // How each struct field got its value
+	required RequiredPolicy                 // Which struct fields must be present
+	coercers map[reflect.Type]customCoercer // Parser-specific custom coercers
+}
+
+// presenceEntry records the FieldPresence of the struct field at path.
+type presenceEntry struct {
+	path     string
+	presence FieldPresence
 }
 
 // NewTypeCoercer creates a new type coercer
 func NewTypeCoercer() *TypeCoercer {
-	return &TypeCoercer{
-		visited: make(map[string]bool),
-	}
+	c := &TypeCoercer{}
+	c.path = c.pathBuf[:0]
+	return c
 }
 
-// Coerce transforms a value to match the target type
+// Coerce transforms a value to match the target type.
+//
+// Struct fields that fail to coerce are left at their zero value and
+// reported together in a *ParseError, which is returned alongside the
+// partial result. Required fields that are absent are reported the same
+// way, with a cause of ErrMissingField.
 func (c *TypeCoercer) Coerce(value interface{}, targetType reflect.Type) (interface{}, *Score, error) {
 	if value == nil {
 		return nil, &Score{total: 0}, nil
 	}
 
+	c.path = c.path[:0]
+	c.errors = nil
+	c.presence = nil
+
 	score := &Score{flags: make(map[string]int)}
 	result, err := c.coerceValue(value, targetType, score)
-	return result, score, err
+	if err != nil {
+		c.errors = append(c.errors, *c.fieldError(value, targetType, err))
+		result = nil
+	}
+	if len(c.errors) > 0 {
+		return result, score, &ParseError{Errors: c.errors}
+	}
+	return result, score, nil
+}
+
+// pushPath descends into a struct field ("age") or element ("[2]").
+func (c *TypeCoercer) pushPath(segment string) {
+	c.path = append(c.path, segment)
+}
+
+// popPath returns to the parent of the current location.
+func (c *TypeCoercer) popPath() {
+	c.path = c.path[:len(c.path)-1]
+}
+
+// currentPath formats the current location, e.g. "employees[2].age".
+func (c *TypeCoercer) currentPath() string {
+	switch len(c.path) {
+	case 0:
+		return ""
+	case 1:
+		return c.path[0]
+	}
+
+	n := 0
+	for _, segment := range c.path {
+		n += len(segment) + 1
+	}
+	var b strings.Builder
+	b.Grow(n)
+	for _, segment := range c.path {
+		if b.Len() > 0 && !strings.HasPrefix(segment, "[") {
+			b.WriteByte('.')
+		}
+		b.WriteString(segment)
+	}
+	return b.String()
+}
+
+// markPresence records how the struct field at the current location got
+// its value.
+func (c *TypeCoercer) markPresence(presence FieldPresence) {
+	c.presence = append(c.presence, presenceEntry{path: c.currentPath(), presence: presence})
+}
+
+// presenceMap returns the recorded presence of each struct field, keyed by
+// path.
+func (c *TypeCoercer) presenceMap() map[string]FieldPresence {
+	m := make(map[string]FieldPresence, len(c.presence))
+	for _, e := range c.presence {
+		m[e.path] = e.presence
+	}
+	return m
+}
+
+// fieldError wraps err in a FieldError for the current location, unless
+// it already is one from deeper in the value.
+func (c *TypeCoercer) fieldError(raw interface{}, target reflect.Type, err error) *FieldError {
+	if fe, ok := err.(*FieldError); ok {
+		return fe
+	}
+	return &FieldError{Path: c.currentPath(), Raw: raw, Target: target, Cause: err}
 }
 
 func (c *TypeCoercer) coerceValue(value interface{}, targetType reflect.Type, score *Score) (interface{}, error) {
@@ -37,8 +128,20 @@ func (c *TypeCoercer) coerceValue(value interface{}, targetType reflect.Type, sc
 		return nil, nil
 	}
 
-	// Handle interface{} target
+	// Custom coercers run before any built-in handling
+	if fn := c.lookupCoercer(targetType); fn != nil {
+		result, err := fn(value, score)
+		if !errors.Is(err, ErrFallback) {
+			return result, err
+		}
+	}
+
+	// Handle interface targets: registered unions pick a concrete variant,
+	// anything else (including interface{}) takes the raw value
 	if targetType.Kind() == reflect.Interface {
+		if def := lookupUnion(targetType); def != nil {
+			return c.coerceToUnion(value, targetType, def, score)
+		}
 		return value, nil
 	}
 
@@ -50,6 +153,20 @@ func (c *TypeCoercer) coerceValue(value interface{}, targetType reflect.Type, sc
 		return value, nil
 	}
 
+	// Enums are matched before null strings so that a value like "none"
+	// can be a legitimate enum member.
+	switch targetType.Kind() {
+	case reflect.String:
+		if def := lookupEnum(targetType); def != nil {
+			return c.coerceToEnum(value, targetType, def, score)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if def := lookupEnum(targetType); def != nil {
+			return c.coerceToIntEnum(value, targetType, def, score)
+		}
+	}
+
 	// Check for null-string variants before type dispatch.
 	// For pointer targets, return nil pointer; for non-pointer targets, return zero value.
 	if s, ok := value.(string); ok && isNullString(s) {
@@ -60,6 +177,12 @@ func (c *TypeCoercer) coerceValue(value interface{}, targetType reflect.Type, sc
 		return reflect.Zero(targetType).Interface(), nil
 	}
 
+	// Types that decode themselves, like uuid.UUID, take precedence over
+	// their underlying kind
+	if isUnmarshaler(targetType) {
+		return c.coerceWithUnmarshaler(value, targetType, score)
+	}
+
 	// Handle pointers
 	if targetType.Kind() == reflect.Ptr {
 		// If value is nil, return nil pointer
@@ -82,7 +205,7 @@ func (c *TypeCoercer) coerceValue(value interface{}, targetType reflect.Type, sc
 	// Handle basic types
 	switch targetType.Kind() {
 	case reflect.String:
-		return c.coerceToString(value, score)
+		return asType(targetType)(c.coerceToString(value, score))
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		return c.coerceToInt(value, targetType, score)
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
@@ -90,7 +213,7 @@ func (c *TypeCoercer) coerceValue(value interface{}, targetType reflect.Type, sc
 	case reflect.Float32, reflect.Float64:
 		return c.coerceToFloat(value, targetType, score)
 	case reflect.Bool:
-		return c.coerceToBool(value, score)
+		return asType(targetType)(c.coerceToBool(value, score))
 	case reflect.Slice:
 		return c.coerceToSlice(value, targetType, score)
 	case reflect.Array:
@@ -107,6 +230,17 @@ func (c *TypeCoercer) coerceValue(value interface{}, targetType reflect.Type, sc
 	}
 }
 
+// asType returns a function that converts a coerced string or bool to
+// targetType, which may be a named type such as `type SKU string`.
+func asType(targetType reflect.Type) func(interface{}, error) (interface{}, error) {
+	return func(result interface{}, err error) (interface{}, error) {
+		if err != nil || result == nil {
+			return result, err
+		}
+		return reflect.ValueOf(result).Convert(targetType).Interface(), nil
+	}
+}
+
 // coerceToString converts value to string
 func (c *TypeCoercer) coerceToString(value interface{}, score *Score) (interface{}, error) {
 	switch v := value.(type) {
@@ -251,6 +385,11 @@ func (c *TypeCoercer) coerceToBool(value interface{}, score *Score) (interface{}
 
 // coerceToSlice converts value to slice
 func (c *TypeCoercer) coerceToSlice(value interface{}, targetType reflect.Type, score *Score) (interface{}, error) {
+	return c.coerceToSliceWith(value, targetType, score, c.coerceValue)
+}
+
+// coerceToSliceWith converts value to slice, coercing each element with coerceElem.
+func (c *TypeCoercer) coerceToSliceWith(value interface{}, targetType reflect.Type, score *Score, coerceElem func(interface{}, reflect.Type, *Score) (interface{}, error)) (interface{}, error) {
 	// Convert to []interface{} first
 	var items []interface{}
 
@@ -282,10 +421,14 @@ func (c *TypeCoercer) coerceToSlice(value interface{}, targetType reflect.Type,
 	result := reflect.MakeSlice(targetType, len(items), len(items))
 
 	for i, item := range items {
-		elem, err := c.coerceValue(item, elemType, score)
+		c.pushPath("[" + strconv.Itoa(i) + "]")
+		elem, err := coerceElem(item, elemType, score)
 		if err != nil {
+			err = c.fieldError(item, elemType, err)
+			c.popPath()
 			return nil, err
 		}
+		c.popPath()
 		result.Index(i).Set(reflect.ValueOf(elem))
 	}
 
@@ -308,10 +451,14 @@ func (c *TypeCoercer) coerceToArray(value interface{}, targetType reflect.Type,
 	elemType := targetType.Elem()
 
 	for i := 0; i < targetType.Len() && i < len(items); i++ {
+		c.pushPath("[" + strconv.Itoa(i) + "]")
 		elem, err := c.coerceValue(items[i], elemType, score)
 		if err != nil {
+			err = c.fieldError(items[i], elemType, err)
+			c.popPath()
 			return nil, err
 		}
+		c.popPath()
 		result.Index(i).Set(reflect.ValueOf(elem))
 	}
 
@@ -330,14 +477,20 @@ func (c *TypeCoercer) coerceToMap(value interface{}, targetType reflect.Type, sc
 	elemType := targetType.Elem()
 
 	for k, v := range mapVal {
+		c.pushPath(k)
 		key, err := c.coerceValue(k, keyType, score)
 		if err != nil {
+			err = c.fieldError(k, keyType, err)
+			c.popPath()
 			return nil, err
 		}
 		elem, err := c.coerceValue(v, elemType, score)
 		if err != nil {
+			err = c.fieldError(v, elemType, err)
+			c.popPath()
 			return nil, err
 		}
+		c.popPath()
 		result.SetMapIndex(reflect.ValueOf(key), reflect.ValueOf(elem))
 	}
 
@@ -369,6 +522,96 @@ func flattenStructFields(t reflect.Type) []structField {
 	return fields
 }
 
+// coerceWithOptions coerces a field value using the options from its
+// `gsap` tag. The options apply to the field's element type, so a tag on a
+// pointer or slice field affects each element.
+func (c *TypeCoercer) coerceWithOptions(value interface{}, targetType reflect.Type, opts fieldOptions, score *Score) (interface{}, error) {
+	switch targetType.Kind() {
+	case reflect.Ptr:
+		if s, ok := value.(string); ok && isNullString(s) {
+			score.AddFlag(FlagNullStringCoerced, 1)
+			return reflect.Zero(targetType).Interface(), nil
+		}
+		elem, err := c.coerceWithOptions(value, targetType.Elem(), opts, score)
+		if err != nil {
+			return nil, err
+		}
+		result := reflect.New(targetType.Elem())
+		result.Elem().Set(reflect.ValueOf(elem))
+		return result.Interface(), nil
+	case reflect.Slice:
+		return c.coerceToSliceWith(value, targetType, score, func(item interface{}, elemType reflect.Type, score *Score) (interface{}, error) {
+			return c.coerceWithOptions(item, elemType, opts, score)
+		})
+	case reflect.Interface:
+		if def := lookupUnion(targetType); def != nil && opts.discriminator != "" {
+			return c.coerceToUnion(value, targetType, def.withOptions(opts), score)
+		}
+	}
+
+	if opts.hasEnum() {
+		return c.coerceToTaggedEnum(value, targetType, opts, score)
+	}
+	return c.coerceValue(value, targetType, score)
+}
+
+// fieldPathName returns the name a field is reported under in error paths:
+// its JSON name if it has one, otherwise its Go name.
+func fieldPathName(field reflect.StructField) string {
+	if tag, ok := field.Tag.Lookup("json"); ok {
+		if name, _, _ := strings.Cut(tag, ","); name != "" && name != "-" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// checkAbsentStruct reports the required fields of an absent struct of type
+// t, at any depth, as missing. Nothing is coerced: the struct is left at its
+// zero value, so its Validate method, custom coercers and constraints don't
+// run on a value the input never sent.
+func (c *TypeCoercer) checkAbsentStruct(t reflect.Type) {
+	plan := structPlanFor(t)
+	for i := range plan.fields {
+		fp := &plan.fields[i]
+		c.pushPath(fp.pathName)
+		switch {
+		case c.isRequired(fp.field, fp.opts):
+			c.errors = append(c.errors, FieldError{Path: c.currentPath(), Target: fp.field.Type, Cause: ErrMissingField})
+		case fp.absentStruct:
+			c.checkAbsentStruct(fp.field.Type)
+		}
+		c.markPresence(FieldMissing)
+		c.popPath()
+	}
+}
+
+// isRequired reports whether field must be present in the input, either
+// because it is tagged `gsap:"required"` or because the coercer's policy
+// requires all non-optional fields. A field with a default is never
+// missing.
+func (c *TypeCoercer) isRequired(field reflect.StructField, opts fieldOptions) bool {
+	if opts.skip || opts.hasDefault || !field.IsExported() {
+		return false
+	}
+	if opts.required {
+		return true
+	}
+	return c.required == RequireNonOptional && field.Type.Kind() != reflect.Ptr && !opts.omitempty
+}
+
+// coerceDefault coerces the default value from a field's tag, using the
+// same rules as input values. Only the DefaultApplied flag is added to
+// score, since the default itself is not part of the input.
+func (c *TypeCoercer) coerceDefault(fieldType reflect.Type, opts fieldOptions, score *Score) (interface{}, error) {
+	elem, err := c.coerceWithOptions(opts.defaultValue, fieldType, opts, &Score{flags: make(map[string]int)})
+	if err != nil {
+		return nil, fmt.Errorf("invalid default %q: %w", opts.defaultValue, err)
+	}
+	score.AddFlag(FlagDefaultApplied, 1)
+	return elem, nil
+}
+
 // coerceToStruct converts value to struct
 func (c *TypeCoercer) coerceToStruct(value interface{}, targetType reflect.Type, score *Score) (interface{}, error) {
 	mapVal, ok := value.(map[string]interface{})
@@ -376,81 +619,152 @@ func (c *TypeCoercer) coerceToStruct(value interface{}, targetType reflect.Type,
 		return nil, fmt.Errorf("cannot convert %T to struct", value)
 	}
 
-	result := reflect.New(targetType).Elem()
-
-	// Flatten fields including embedded structs
-	fields := flattenStructFields(targetType)
-	hasEmbedded := len(fields) != targetType.NumField()
-
-	for _, sf := range fields {
-		field := sf.field
-		fieldType := field.Type
-
-		// Find matching key in map
-		var mapKey string
-		var mapValue interface{}
-
-		// Try JSON tag first
-		if tag, ok := field.Tag.Lookup("json"); ok {
-			parts := strings.Split(tag, ",")
-			if parts[0] != "" && parts[0] != "-" {
-				if v, ok := mapVal[parts[0]]; ok {
-					mapKey = parts[0]
-					mapValue = v
-				}
-			}
-		}
+	mark := len(c.errors)
+	plan := structPlanFor(targetType)
+	keys := plan.matchKeys(mapVal)
 
-		// Try field name
-		if mapKey == "" {
-			if v, ok := mapVal[field.Name]; ok {
-				mapKey = field.Name
-				mapValue = v
-			}
-		}
+	c.presence = slices.Grow(c.presence, len(plan.fields))
 
-		// Try case-insensitive match
-		if mapKey == "" {
-			for k, v := range mapVal {
-				if strings.EqualFold(k, field.Name) {
-					mapKey = k
-					mapValue = v
-					score.AddFlag(FlagFuzzyFieldMatch, 1)
-					break
-				}
-			}
-		}
-
-		// If found, coerce and set
-		if mapKey != "" {
-			elem, err := c.coerceValue(mapValue, fieldType, score)
-			if err != nil {
-				// Skip fields that fail to coerce if they're optional
+	var result reflect.Value
+	if gen := lookupGenerated(targetType); gen != nil {
+		// A generated coercer sets the fields itself
+		result = gen(&StructState{c: c, plan: plan, keys: keys, score: score})
+	} else {
+		result = reflect.New(targetType).Elem()
+		for i := range plan.fields {
+			fp := &plan.fields[i]
+			elem, ok := c.coerceField(&keys, i, score)
+			if !ok {
 				continue
 			}
 
 			// Navigate to the field using the index path
 			target := result
-			for _, idx := range sf.index {
+			for _, idx := range fp.index {
 				target = target.Field(idx)
 			}
 
 			// Handle nil values properly - use zero value for the type
 			if elem == nil {
-				target.Set(reflect.Zero(fieldType))
+				target.Set(reflect.Zero(fp.field.Type))
 			} else {
 				target.Set(reflect.ValueOf(elem))
 			}
 		}
 	}
 
-	if hasEmbedded {
+	if plan.hasEmbedded {
 		score.AddFlag(FlagEmbeddedStruct, 0)
 	}
 
+	// Cross-field invariants only make sense once every field parsed
+	if len(c.errors) == mark {
+		c.validate(result, score)
+	}
+
 	return result.Interface(), nil
 }
 
+// fromNullString reports whether elem, coerced from the input string s,
+// is the zero value a null-like string such as "N/A" stands for. A string
+// that names an enum member, like "none" in enum=none|low|high, is a real
+// value even if the member is the zero value.
+func fromNullString(s string, elem interface{}, fieldType reflect.Type, opts fieldOptions) bool {
+	if !isNullString(s) || (elem != nil && !reflect.ValueOf(elem).IsZero()) {
+		return false
+	}
+	if def := opts.enumDef(fieldType); def != nil {
+		if _, ok := matchEnum(s, def.candidates(), &Score{}); ok {
+			return false
+		}
+	}
+	return true
+}
+
+// coerceField coerces the input value for field i of the plan, applying
+// its tag options and recording its presence. It reports false if the
+// field should be left at its zero value.
+func (c *TypeCoercer) coerceField(keys *keyMatcher, i int, score *Score) (interface{}, bool) {
+	fp := &keys.plan.fields[i]
+	field := fp.field
+	fieldType := field.Type
+	opts := fp.opts
+
+	// Find matching key in map
+	mapKey, fuzzy := keys.find(i)
+	mapValue := keys.mapVal[mapKey]
+	if fuzzy {
+		score.AddFlag(FlagFuzzyFieldMatch, 1)
+	}
+
+	c.pushPath(fp.pathName)
+	presence := FieldPopulated
+	switch {
+	case mapKey == "":
+		presence = FieldMissing
+	case mapValue == nil:
+		presence = FieldNull
+	}
+
+	if mapValue == nil && c.isRequired(field, opts) {
+		c.errors = append(c.errors, FieldError{Path: c.currentPath(), Target: fieldType, Cause: ErrMissingField})
+		c.markPresence(presence)
+		c.popPath()
+		return nil, false
+	}
+
+	// An absent struct field still has to satisfy its own required fields
+	if mapKey == "" && fp.absentStruct {
+		c.checkAbsentStruct(fieldType)
+	}
+
+	if mapKey == "" && !opts.hasDefault {
+		c.markPresence(presence)
+		c.popPath()
+		return nil, false
+	}
+
+	// Coerce
+	var elem interface{}
+	var err error
+	switch {
+	case mapKey == "":
+		// Nothing to coerce; the default is applied below
+	default:
+		elem, err = fp.coerce(c, mapValue, score)
+	}
+	if err != nil {
+		// Record the failure and leave the field at its zero value
+		c.errors = append(c.errors, *c.fieldError(mapValue, fieldType, err))
+		c.popPath()
+		return nil, false
+	}
+	if s, ok := mapValue.(string); ok && fromNullString(s, elem, fieldType, opts) {
+		presence = FieldNullString
+	}
+
+	// Apply the default when the input had no real value
+	if opts.hasDefault && presence != FieldPopulated {
+		elem, err = c.coerceDefault(fieldType, opts, score)
+		if err != nil {
+			c.errors = append(c.errors, *c.fieldError(opts.defaultValue, fieldType, err))
+			c.popPath()
+			return nil, false
+		}
+		presence = FieldDefaulted
+	}
+
+	// Constraints apply to values from the input; a violating field is
+	// left at its zero value
+	if presence == FieldPopulated && opts.hasConstraints() && !c.checkConstraints(elem, fieldType, opts, score) {
+		c.popPath()
+		return nil, false
+	}
+	c.markPresence(presence)
+	c.popPath()
+	return elem, true
+}
+
 // stripMarkdown removes markdown bold/italic formatting from a string.
 // e.g. "**42**" → "42", "_30_" → "30", "*text*" → "text"
 func stripMarkdown(s string) (string, bool) {
@@ -565,7 +879,24 @@ var nullStrings = map[string]bool{
 	"--":      true,
 }
 
+// maxNullString is the length of the longest of nullStrings.
+const maxNullString = len("undefined")
+
 // isNullString checks if a string is a null-like value.
 func isNullString(s string) bool {
-	return nullStrings[strings.ToLower(strings.TrimSpace(s))]
+	s = strings.TrimSpace(s)
+	if len(s) > maxNullString {
+		return false
+	}
+	// Lowercase into a buffer rather than a new string; the null strings
+	// are all ASCII
+	var lower [maxNullString]byte
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if 'A' <= ch && ch <= 'Z' {
+			ch += 'a' - 'A'
+		}
+		lower[i] = ch
+	}
+	return nullStrings[string(lower[:len(s)])]
 }
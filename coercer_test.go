@@ -48,6 +48,21 @@ func TestCoerceToString(t *testing.T) {
 	}
 }
 
+func TestCoerceToNamedStringAndBool(t *testing.T) {
+	type label string
+	type flag bool
+	c := NewTypeCoercer()
+
+	got, err := c.coerceValue(float64(7), reflect.TypeOf(label("")), newScore())
+	if err != nil || got != label("7") {
+		t.Errorf("coerceValue to label = %#v, %v; want label(\"7\")", got, err)
+	}
+	got, err = c.coerceValue("yes", reflect.TypeOf(flag(false)), newScore())
+	if err != nil || got != flag(true) {
+		t.Errorf("coerceValue to flag = %#v, %v; want flag(true)", got, err)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // coerceToUint
 // ---------------------------------------------------------------------------
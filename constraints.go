@@ -0,0 +1,195 @@
+package sap
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// reEmail is deliberately loose: one @, no spaces, and a dot in the domain.
+var reEmail = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+
+// reUUID matches a UUID in its canonical hyphenated form.
+var reUUID = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// stringFormats holds the checks for format= options.
+var stringFormats = map[string]func(string) bool{
+	"email": reEmail.MatchString,
+	"uuid":  reUUID.MatchString,
+	"url": func(s string) bool {
+		u, err := url.Parse(s)
+		return err == nil && u.Scheme != "" && u.Host != ""
+	},
+	"date": func(s string) bool {
+		_, err := time.Parse("2006-01-02", s)
+		return err == nil
+	},
+	"date-time": func(s string) bool {
+		_, err := time.Parse(time.RFC3339, s)
+		return err == nil
+	},
+}
+
+// patternCache holds compiled pattern= options, keyed by expression.
+var patternCache sync.Map // string -> *regexp.Regexp
+
+// compilePattern compiles a pattern= option, caching the result.
+func compilePattern(expr string) (*regexp.Regexp, error) {
+	if re, ok := patternCache.Load(expr); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q in gsap tag: %w", expr, err)
+	}
+	patternCache.Store(expr, re)
+	return re, nil
+}
+
+// constraintViolation is a value that broke a constraint.
+type constraintViolation struct {
+	path  string // relative to the field, e.g. "[2]" for a slice element
+	value interface{}
+	err   error
+}
+
+// violations checks v against the constraints in o. The length bound
+// applies to strings, slices, arrays and maps; the other constraints apply
+// to numbers and strings, or to each element of a slice or array.
+func (o fieldOptions) violations(v reflect.Value) []constraintViolation {
+	if o.err != nil {
+		return []constraintViolation{{err: o.err}}
+	}
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil
+	}
+
+	var out []constraintViolation
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if err := o.checkLen(v.Len()); err != nil {
+			out = append(out, constraintViolation{value: v.Interface(), err: err})
+		}
+		if v.Kind() == reflect.Map {
+			break
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := o.checkScalar(v.Index(i)); err != nil {
+				out = append(out, constraintViolation{path: "[" + strconv.Itoa(i) + "]", value: v.Index(i).Interface(), err: err})
+			}
+		}
+	case reflect.String:
+		err := o.checkLen(utf8.RuneCountInString(v.String()))
+		if err == nil {
+			err = o.checkScalar(v)
+		}
+		if err != nil {
+			out = append(out, constraintViolation{value: v.Interface(), err: err})
+		}
+	default:
+		if err := o.checkScalar(v); err != nil {
+			out = append(out, constraintViolation{value: v.Interface(), err: err})
+		}
+	}
+	return out
+}
+
+// checkLen checks a length against the len= bounds.
+func (o fieldOptions) checkLen(n int) error {
+	if o.minLen != nil && n < *o.minLen {
+		return fmt.Errorf("%w: length %d is less than min length %d", ErrConstraint, n, *o.minLen)
+	}
+	if o.maxLen != nil && n > *o.maxLen {
+		return fmt.Errorf("%w: length %d is greater than max length %d", ErrConstraint, n, *o.maxLen)
+	}
+	return nil
+}
+
+// checkScalar checks a number against min= and max=, or a string against
+// pattern= and format=.
+func (o fieldOptions) checkScalar(v reflect.Value) error {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	var f float64
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		f = float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		f = float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		f = v.Float()
+	case reflect.String:
+		return o.checkString(v.String())
+	default:
+		return nil
+	}
+
+	if o.min != nil && f < *o.min {
+		return fmt.Errorf("%w: %s is less than min %s", ErrConstraint, formatFloat(f), formatFloat(*o.min))
+	}
+	if o.max != nil && f > *o.max {
+		return fmt.Errorf("%w: %s is greater than max %s", ErrConstraint, formatFloat(f), formatFloat(*o.max))
+	}
+	return nil
+}
+
+// checkString checks a string against pattern= and format=.
+func (o fieldOptions) checkString(s string) error {
+	if o.pattern != "" {
+		re, err := compilePattern(o.pattern)
+		if err != nil {
+			return err
+		}
+		if !re.MatchString(s) {
+			return fmt.Errorf("%w: %q does not match pattern %s", ErrConstraint, s, o.pattern)
+		}
+	}
+	if o.format != "" {
+		valid, ok := stringFormats[o.format]
+		if !ok {
+			return fmt.Errorf("unknown format %q in gsap tag", o.format)
+		}
+		if !valid(s) {
+			return fmt.Errorf("%w: %q is not a valid %s", ErrConstraint, s, o.format)
+		}
+	}
+	return nil
+}
+
+// formatFloat formats f without trailing zeros, e.g. 120 or 0.5.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// checkConstraints checks a coerced field value against its constraints.
+// Each violation is recorded as a FieldError and adds the
+// ConstraintViolated penalty to score. It reports whether value passed.
+func (c *TypeCoercer) checkConstraints(value interface{}, fieldType reflect.Type, opts fieldOptions, score *Score) bool {
+	violations := opts.violations(reflect.ValueOf(value))
+	for _, v := range violations {
+		c.errors = append(c.errors, FieldError{
+			Path:   c.currentPath() + v.path,
+			Raw:    v.value,
+			Target: fieldType,
+			Cause:  v.err,
+		})
+		score.AddFlag(FlagConstraintViolated, 5)
+	}
+	return len(violations) == 0
+}
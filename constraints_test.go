@@ -0,0 +1,118 @@
+package sap
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testPatient struct {
+	Name     string    `json:"name" gsap:"len=1..40"`
+	Age      int       `json:"age" gsap:"min=0,max=120"`
+	Ward     string    `json:"ward" gsap:"pattern=^[A-Z]{3}$"`
+	Email    string    `json:"email" gsap:"format=email"`
+	Readings []float64 `json:"readings" gsap:"len=..3,min=30,max=45"`
+}
+
+func TestParseConstraintsPenalize(t *testing.T) {
+	input := `{"name": "Ada", "age": "-5 years", "ward": "icu", "email": "ada-at-example", "readings": [36.6, 12]}`
+	got, score, err := ParseWithScore[testPatient](input)
+
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected *ParseError, got %v", err)
+	}
+	if !errors.Is(err, ErrConstraint) {
+		t.Errorf("expected errors.Is(err, ErrConstraint)")
+	}
+	var paths []string
+	for _, fe := range perr.Errors {
+		paths = append(paths, fe.Path)
+	}
+	if want := []string{"age", "ward", "email", "readings[1]"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("paths = %v, want %v", paths, want)
+	}
+	if msg := perr.Errors[0].Error(); msg != "age: constraint violated: -5 is less than min 0" {
+		t.Errorf("unexpected message %q", msg)
+	}
+
+	// Violating fields keep their zero value; the rest parse
+	if got.Name != "Ada" || got.Age != 0 || got.Ward != "" || got.Readings != nil {
+		t.Errorf("unexpected result %+v", got)
+	}
+	if _, ok := score.Flags()[FlagConstraintViolated]; !ok {
+		t.Errorf("expected %s flag, got %v", FlagConstraintViolated, score.Flags())
+	}
+}
+
+func TestParseConstraintsReject(t *testing.T) {
+	parser := NewParser().WithConstraintPolicy(RejectViolations)
+	target := reflect.TypeOf(testPatient{})
+
+	// The first candidate breaks the age limit, so the second wins
+	input := `First try: {"name": "Ada", "age": 300} Corrected: {"name": "Ada", "age": 30}`
+	result, err := parser.Parse(input, target)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if got := result.(testPatient); got.Age != 30 {
+		t.Errorf("Age = %d, want 30", got.Age)
+	}
+
+	// With no valid candidate the parse fails outright
+	result, err = parser.Parse(`{"name": "Ada", "age": 300}`, target)
+	if !errors.Is(err, ErrConstraint) {
+		t.Fatalf("expected ErrConstraint, got %v", err)
+	}
+	if result != nil {
+		t.Errorf("expected no result, got %+v", result)
+	}
+}
+
+func TestConstraintViolations(t *testing.T) {
+	tests := []struct {
+		name    string
+		tag     reflect.StructTag
+		value   interface{}
+		wantErr string
+	}{
+		{"within range", `gsap:"min=1,max=5"`, 3, ""},
+		{"below min", `gsap:"min=1"`, 0, "0 is less than min 1"},
+		{"above max float", `gsap:"max=0.5"`, 0.75, "0.75 is greater than max 0.5"},
+		{"uint max", `gsap:"max=10"`, uint8(11), "11 is greater than max 10"},
+		{"nil pointer skipped", `gsap:"min=1"`, (*int)(nil), ""},
+		{"string too short", `gsap:"len=2..4"`, "é", "length 1 is less than min length 2"},
+		{"exact length", `gsap:"len=3"`, "abcd", "length 4 is greater than max length 3"},
+		{"open lower bound", `gsap:"len=2.."`, []string{"a", "b", "c"}, ""},
+		{"map length", `gsap:"len=..1"`, map[string]int{"a": 1, "b": 2}, "length 2 is greater than max length 1"},
+		{"pattern", `gsap:"pattern='^[a-z]{2,3}$'"`, "abcd", `"abcd" does not match pattern ^[a-z]{2,3}$`},
+		{"email", `gsap:"format=email"`, "a@b.co", ""},
+		{"url", `gsap:"format=url"`, "example.com", `"example.com" is not a valid url`},
+		{"uuid", `gsap:"format=uuid"`, "123e4567-e89b-12d3-a456-426614174000", ""},
+		{"date", `gsap:"format=date"`, "2024-02-30", `"2024-02-30" is not a valid date`},
+		{"unknown format", `gsap:"format=zip"`, "12345", `unknown format "zip"`},
+		{"bad pattern", `gsap:"pattern=("`, "x", "invalid pattern"},
+		{"bad min", `gsap:"min=low"`, 1, `invalid min "low"`},
+		{"bad len", `gsap:"len=a..b"`, "x", `invalid len "a..b"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := parseFieldOptions(tt.tag)
+			if !opts.hasConstraints() {
+				t.Fatalf("expected constraints from %s", tt.tag)
+			}
+			got := opts.violations(reflect.ValueOf(tt.value))
+			if tt.wantErr == "" {
+				if len(got) != 0 {
+					t.Errorf("unexpected violations %v", got[0].err)
+				}
+				return
+			}
+			if len(got) != 1 || !strings.Contains(got[0].err.Error(), tt.wantErr) {
+				t.Errorf("violations = %v, want one containing %q", got, tt.wantErr)
+			}
+		})
+	}
+}
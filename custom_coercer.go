@@ -0,0 +1,88 @@
+package sap
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+)
+
+// ErrFallback can be returned by a custom coercer to hand the raw value to
+// the built-in coercion for the type, e.g. when it only handles some input
+// shapes.
+var ErrFallback = errors.New("fall back to default coercion")
+
+// CoerceFunc converts a raw JSON value (string, float64, bool,
+// []interface{} or map[string]interface{}) to T. It may add its own flags
+// to score to record how much the input had to be transformed.
+type CoerceFunc[T any] func(raw interface{}, score *Score) (T, error)
+
+// customCoercer is a CoerceFunc with its result type erased.
+type customCoercer func(raw interface{}, score *Score) (interface{}, error)
+
+var (
+	coercerMu       sync.RWMutex
+	coercerRegistry = make(map[reflect.Type]customCoercer)
+)
+
+// RegisterCoercer installs fn as the coercion for T in every parser. It
+// runs before any built-in handling, including enums and null strings, for
+// T wherever it appears: as the target, a field, or an element.
+//
+//	sap.RegisterCoercer(func(raw interface{}, s *sap.Score) (SKU, error) {
+//	    str, ok := raw.(string)
+//	    if !ok {
+//	        return "", sap.ErrFallback
+//	    }
+//	    if !strings.HasPrefix(str, "SKU-") {
+//	        s.AddFlag("SKUPrefixAdded", 1)
+//	        str = "SKU-" + str
+//	    }
+//	    return SKU(strings.ToUpper(str)), nil
+//	})
+//
+// Returning an error wrapping ErrFallback runs the built-in coercion on
+// the original raw value instead; any flags already added are kept.
+func RegisterCoercer[T any](fn CoerceFunc[T]) {
+	coercerMu.Lock()
+	defer coercerMu.Unlock()
+	coercerRegistry[reflect.TypeOf((*T)(nil)).Elem()] = eraseCoercer(fn)
+}
+
+// WithCoercer installs fn as the coercion for T in one parser, taking
+// precedence over RegisterCoercer:
+//
+//	p := sap.New(sap.WithCoercer(parseMoney))
+func WithCoercer[T any](fn CoerceFunc[T]) Option {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	return func(p *Parser) {
+		// Copy so that parsers sharing the map are unaffected
+		coercers := make(map[reflect.Type]customCoercer, len(p.coercers)+1)
+		for k, v := range p.coercers {
+			coercers[k] = v
+		}
+		coercers[t] = eraseCoercer(fn)
+		p.coercers = coercers
+	}
+}
+
+// eraseCoercer adapts a typed CoerceFunc to a customCoercer.
+func eraseCoercer[T any](fn CoerceFunc[T]) customCoercer {
+	return func(raw interface{}, score *Score) (interface{}, error) {
+		v, err := fn(raw, score)
+		if err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+}
+
+// lookupCoercer returns the custom coercer for t: the parser's own, then
+// a registered one, or nil.
+func (c *TypeCoercer) lookupCoercer(t reflect.Type) customCoercer {
+	if fn, ok := c.coercers[t]; ok {
+		return fn
+	}
+	coercerMu.RLock()
+	defer coercerMu.RUnlock()
+	return coercerRegistry[t]
+}
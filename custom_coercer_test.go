@@ -0,0 +1,119 @@
+package sap
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testSKU string
+
+type testCountry string
+
+type testOrderLine struct {
+	SKU     testSKU       `json:"sku"`
+	Ship    testCountry   `json:"ship_to"`
+	Origins []testCountry `json:"origins"`
+}
+
+var testCountryNames = map[string]testCountry{
+	"united states": "US",
+	"germany":       "DE",
+}
+
+func init() {
+	RegisterCoercer(func(raw interface{}, s *Score) (testSKU, error) {
+		str, ok := raw.(string)
+		if !ok {
+			return "", fmt.Errorf("SKU must be a string, got %T", raw)
+		}
+		str = strings.ToUpper(strings.TrimSpace(str))
+		if !strings.HasPrefix(str, "SKU-") {
+			s.AddFlag("SKUPrefixAdded", 1)
+			str = "SKU-" + str
+		}
+		return testSKU(str), nil
+	})
+
+	RegisterCoercer(func(raw interface{}, s *Score) (testCountry, error) {
+		str, ok := raw.(string)
+		if !ok {
+			return "", ErrFallback
+		}
+		if code, ok := testCountryNames[strings.ToLower(str)]; ok {
+			s.AddFlag("CountryFromName", 1)
+			return code, nil
+		}
+		return "", ErrFallback
+	})
+}
+
+func TestParseCustomCoercer(t *testing.T) {
+	input := `{"sku": "ab-12", "ship_to": "Germany", "origins": ["United States", "FR", 44]}`
+	line, score, err := ParseWithScore[testOrderLine](input)
+	if err != nil {
+		t.Fatalf("ParseWithScore failed: %v", err)
+	}
+
+	want := testOrderLine{SKU: "SKU-AB-12", Ship: "DE", Origins: []testCountry{"US", "FR", "44"}}
+	if !reflect.DeepEqual(line, want) {
+		t.Errorf("got %+v, want %+v", line, want)
+	}
+	for _, flag := range []string{"SKUPrefixAdded", "CountryFromName"} {
+		if _, ok := score.Flags()[flag]; !ok {
+			t.Errorf("expected custom flag %s, got %v", flag, score.Flags())
+		}
+	}
+}
+
+func TestParseCustomCoercerError(t *testing.T) {
+	_, err := Parse[testOrderLine](`{"sku": 42}`)
+	var perr *ParseError
+	if !errors.As(err, &perr) || perr.Errors[0].Path != "sku" {
+		t.Fatalf("expected a field error for sku, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "SKU must be a string") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestWithCoercerOverridesRegistered(t *testing.T) {
+	parser := New(WithCoercer(func(raw interface{}, s *Score) (testSKU, error) {
+		return testSKU(fmt.Sprintf("LOCAL-%v", raw)), nil
+	}))
+
+	result, err := parser.Parse(`{"sku": "ab-12"}`, reflect.TypeOf(testOrderLine{}))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if got := result.(testOrderLine).SKU; got != "LOCAL-ab-12" {
+		t.Errorf("SKU = %q, want the parser's coercer", got)
+	}
+
+	// Other parsers keep the registered coercer
+	line, err := Parse[testOrderLine](`{"sku": "ab-12"}`)
+	if err != nil || line.SKU != "SKU-AB-12" {
+		t.Errorf("default parser: got %q, %v", line.SKU, err)
+	}
+}
+
+func TestCustomCoercerSkipsAbsentStruct(t *testing.T) {
+	type point struct {
+		X, Y int
+	}
+	type shape struct {
+		Name   string `json:"name"`
+		Center point  `json:"center"`
+	}
+	called := false
+	RegisterCoercer(func(raw interface{}, s *Score) (point, error) {
+		called = true
+		return point{}, errors.New("point must be a pair")
+	})
+
+	if _, err := Parse[shape](`{"name": "dot"}`); err != nil || called {
+		t.Errorf("expected the coercer to be skipped for an absent field, got %v (called %v)", err, called)
+	}
+}
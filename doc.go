@@ -62,10 +62,45 @@
 //   - Bool to int: true is 1, false is 0
 //   - Case-insensitive struct field matching
 //   - Fuzzy enum matching with Unicode normalization
+//   - Types implementing json.Unmarshaler or encoding.TextUnmarshaler,
+//     such as uuid.UUID, decode themselves, with a retry that strips
+//     markdown and units
+//
+// RegisterCoercer installs a custom coercion for a type, which runs before
+// the built-in rules and can return ErrFallback to defer to them.
 //
 // Each coercion adds a penalty to the parse score so you can distinguish
 // a clean parse from one that required significant transformation.
 //
+// # Errors
+//
+// Fields that fail to coerce don't abort the parse. They are left at their
+// zero value and reported together in a *ParseError, which is returned
+// alongside the partial result. Each FieldError carries the path of the
+// failed value (e.g. "employees[2].age"), the raw input, the target type
+// and the cause:
+//
+//	dept, err := sap.Parse[Department](llmResponse)
+//	var perr *sap.ParseError
+//	if errors.As(err, &perr) {
+//	    // dept holds everything that did parse
+//	}
+//
+// Use errors.Is with ErrNoJSON, ErrCoercion, ErrAmbiguous, ErrMissingField,
+// ErrConstraint and ErrValidation to classify failures.
+//
+// # Constraints
+//
+// Struct tags such as `gsap:"min=0,max=120"`, `gsap:"len=2..10"`,
+// `gsap:"pattern=^[A-Z]{3}$"` and `gsap:"format=email"` are checked after
+// coercion. A violation either penalizes the candidate and reports a
+// FieldError, or, under RejectViolations, discards the candidate so that
+// another one can win.
+//
+// Invariants that span fields belong in a Validate() error method (see
+// Validator), which is called on the target and every nested struct. Its
+// errors are reported like field errors and count against the candidate.
+//
 // # Streaming
 //
 // For streaming LLM responses, ParsePartial accepts incomplete JSON and
@@ -73,6 +108,60 @@
 //
 //	user, state, err := sap.ParsePartial[User](partialResponse)
 //
+// The state is Incomplete while required fields are missing. Fields are
+// required when tagged `gsap:"required"`, or under the RequireNonOptional
+// policy when they are neither pointers nor omitempty. A full parse
+// reports missing required fields as errors matching ErrMissingField;
+// ParseDetailed and ParsePartialDetailed also list them in
+// ParseResult.Missing.
+//
+// ParseResult.Presence records, for each struct field path, whether the
+// value came from the input, was missing, was null, or came from a
+// `gsap:"default=..."` tag, so results can be merged into existing data
+// without overwriting it with zero values.
+//
+// # Configuration
+//
+// A Parser is built from options and is immutable, so it is safe for
+// concurrent use:
+//
+//	parser := sap.New(sap.WithStrict(), sap.WithMaxScore(10))
+//
+// The generic functions use DefaultParser, with any options passed to
+// them applied to a copy of it.
+//
+// # JSON Schema
+//
+// JSONSchema describes the JSON that Parse[T] expects as a draft 2020-12
+// JSON Schema, using the same field names, enums, unions and tag options
+// as coercion. StrictSchema adapts it to providers' strict structured
+// output modes.
+//
+// ValidateAgainstSchema checks a value against a JSON Schema document,
+// reporting violations as FieldErrors matching ErrSchema; WithJSONSchema
+// makes a parser check every result.
+//
+// For targets defined at runtime, a Schema describes the JSON instead of a
+// Go type. Build one in code or with SchemaFromJSON and parse with
+// ParseDynamic, which returns a map[string]interface{}.
+//
+// RenderFormat describes the same JSON in a compact, TypeScript-like form
+// for prompts, with descriptions and enum values as comments, and Grammar
+// as a GBNF grammar for constrained decoding with local models. Example
+// generates a sample answer for few-shot prompts.
+//
+// Reask calls a model through a Model callback and parses its reply. It
+// asks again with a corrective message listing the failed and missing
+// fields until a reply parses or the retries run out.
+//
+// # Generated Coercers
+//
+// The gsapgen command generates coercers that convert struct fields of
+// basic kinds, and nested generated structs, without reflection when the
+// input already has the right JSON type; other fields take the usual path.
+// They register themselves with RegisterGenerated, so Parse uses them
+// automatically; Coerce converts already decoded JSON.
+//
 // # instructor-go Integration
 //
 // To use sap as the parser for instructor-go, create an InstructorParser:
@@ -0,0 +1,416 @@
+package sap
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// SchemaType is the kind of value a Schema describes.
+type SchemaType string
+
+// Schema types.
+const (
+	TypeAny     SchemaType = "" // any JSON value, kept as decoded
+	TypeObject  SchemaType = "object"
+	TypeArray   SchemaType = "array"
+	TypeString  SchemaType = "string"
+	TypeInteger SchemaType = "integer"
+	TypeNumber  SchemaType = "number"
+	TypeBoolean SchemaType = "boolean"
+)
+
+// Schema describes the JSON to parse when there is no Go type for it, such
+// as an extraction target configured at runtime. Build one in code:
+//
+//	schema := sap.Schema{
+//	    Type: sap.TypeObject,
+//	    Properties: []sap.Property{
+//	        {Name: "name", Schema: sap.Schema{Type: sap.TypeString}},
+//	        {Name: "status", Schema: sap.Schema{Type: sap.TypeString, Enum: []string{"open", "closed"}}},
+//	        {Name: "tags", Schema: sap.Schema{Type: sap.TypeArray, Items: &sap.Schema{Type: sap.TypeString}}, Optional: true},
+//	    },
+//	}
+//
+// or load it from a JSON Schema document with SchemaFromJSON, and pass it
+// to ParseDynamic.
+type Schema struct {
+	Type        SchemaType
+	Description string
+	Nullable    bool       // null is allowed
+	Properties  []Property // for objects; none allows any properties
+	Items       *Schema    // for arrays; nil allows any items
+	Enum        []string   // for strings, the allowed values
+}
+
+// Property is a property of an object Schema.
+type Property struct {
+	Name     string
+	Schema   Schema
+	Optional bool // the property may be left out
+}
+
+// SchemaFromJSON builds a Schema from a JSON Schema document. It reads
+// type, properties, required, items, enum, const and description; a type
+// list or anyOf including "null" makes a schema nullable, and references
+// within the document are followed. Other keywords are ignored.
+//
+// It returns an error for schemas a Schema can't describe, such as unions
+// of several types and recursive references.
+func SchemaFromJSON(data []byte) (Schema, error) {
+	var root interface{}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return Schema{}, fmt.Errorf("invalid schema: %w", err)
+	}
+	r := &schemaReader{root: root, active: make(map[string]bool)}
+	s, err := r.read(root, "#")
+	if err != nil {
+		return Schema{}, fmt.Errorf("invalid schema: %w", err)
+	}
+	return s, nil
+}
+
+// schemaReader converts a JSON Schema document to a Schema.
+type schemaReader struct {
+	root   interface{}
+	active map[string]bool // references being read, to detect recursion
+}
+
+// read converts the schema raw found at pointer.
+func (r *schemaReader) read(raw interface{}, pointer string) (Schema, error) {
+	if b, ok := raw.(bool); ok && b {
+		return Schema{}, nil
+	}
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return Schema{}, fmt.Errorf("%s: unsupported schema %v", pointer, raw)
+	}
+
+	if ref, ok := m["$ref"].(string); ok {
+		if r.active[ref] {
+			return Schema{}, fmt.Errorf("%s: recursive reference %q is not supported", pointer, ref)
+		}
+		target, err := (&schemaCompiler{root: r.root}).lookup(ref)
+		if err != nil {
+			return Schema{}, fmt.Errorf("%s: %w", pointer, err)
+		}
+		r.active[ref] = true
+		defer delete(r.active, ref)
+		return r.read(target, ref)
+	}
+
+	var s Schema
+	s.Description, _ = m["description"].(string)
+
+	for _, key := range []string{"anyOf", "oneOf"} {
+		variants, ok := m[key].([]interface{})
+		if !ok {
+			continue
+		}
+		var other []interface{}
+		for _, v := range variants {
+			if vm, ok := v.(map[string]interface{}); ok && vm["type"] == "null" {
+				s.Nullable = true
+			} else {
+				other = append(other, v)
+			}
+		}
+		if len(other) != 1 {
+			return Schema{}, fmt.Errorf("%s/%s: unions of several types are not supported", pointer, key)
+		}
+		inner, err := r.read(other[0], pointer+"/"+key)
+		if err != nil {
+			return Schema{}, err
+		}
+		inner.Nullable = inner.Nullable || s.Nullable
+		if inner.Description == "" {
+			inner.Description = s.Description
+		}
+		return inner, nil
+	}
+
+	switch t := m["type"].(type) {
+	case string:
+		s.Type = SchemaType(t)
+	case []interface{}:
+		for _, v := range t {
+			switch {
+			case v == "null":
+				s.Nullable = true
+			case s.Type != "":
+				return Schema{}, fmt.Errorf("%s/type: unions of several types are not supported", pointer)
+			default:
+				name, _ := v.(string)
+				s.Type = SchemaType(name)
+			}
+		}
+	}
+	if s.Type == "null" {
+		return Schema{}, fmt.Errorf("%s/type: null is only supported alongside another type", pointer)
+	}
+
+	var values []interface{}
+	if c, ok := m["const"]; ok {
+		values = []interface{}{c}
+	} else if e, ok := m["enum"].([]interface{}); ok {
+		values = e
+	}
+	for _, v := range values {
+		switch v := v.(type) {
+		case string:
+			s.Enum = append(s.Enum, v)
+		case nil:
+			s.Nullable = true
+		default:
+			return Schema{}, fmt.Errorf("%s/enum: only string values are supported, got %v", pointer, v)
+		}
+	}
+	if s.Enum != nil && s.Type == TypeAny {
+		s.Type = TypeString
+	}
+
+	switch s.Type {
+	case TypeObject:
+		props, _ := m["properties"].(map[string]interface{})
+		required := make(map[string]bool)
+		if list, ok := m["required"].([]interface{}); ok {
+			for _, name := range list {
+				if name, ok := name.(string); ok {
+					required[name] = true
+				}
+			}
+		}
+
+		// JSON objects are unordered, so sort the properties to keep
+		// parses deterministic
+		names := make([]string, 0, len(props))
+		for name := range props {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			ps, err := r.read(props[name], pointer+"/properties/"+escapePointer(name))
+			if err != nil {
+				return Schema{}, err
+			}
+			s.Properties = append(s.Properties, Property{Name: name, Schema: ps, Optional: !required[name]})
+		}
+	case TypeArray:
+		if items, ok := m["items"]; ok {
+			is, err := r.read(items, pointer+"/items")
+			if err != nil {
+				return Schema{}, err
+			}
+			s.Items = &is
+		}
+	}
+	return s, nil
+}
+
+// ParseDynamic parses input with DefaultParser, or a copy of it with opts
+// applied, as described by schema. See Parser.ParseDynamic.
+func ParseDynamic(input string, schema Schema, opts ...Option) (map[string]interface{}, *Score, error) {
+	return parserFor(opts).ParseDynamic(input, schema)
+}
+
+// ParseDynamic parses input as described by schema, which must describe
+// an object, with the same extraction, fixing and coercion as a typed
+// parse and the same scoring. The result holds string, int64, float64,
+// bool, nil, []interface{} and map[string]interface{} values, with a key
+// for each property present in the input or not optional. A nullable
+// property that isn't optional is null when it is missing.
+//
+// As with Parse, failed properties are reported in a *ParseError alongside
+// the partial result.
+func (p *Parser) ParseDynamic(input string, schema Schema) (map[string]interface{}, *Score, error) {
+	if schema.Type != TypeObject {
+		return nil, nil, fmt.Errorf("cannot parse dynamically: schema must describe an object, not %q", schema.Type)
+	}
+	t, err := schema.goType("")
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot parse dynamically: %w", err)
+	}
+
+	res, err := p.parse(input, t, false)
+	if res == nil {
+		return nil, nil, err
+	}
+	out, _ := schema.normalize(reflect.ValueOf(res.Value), "", res.Presence).(map[string]interface{})
+	return out, res.Score, err
+}
+
+// goType returns the Go type that values of the schema are coerced to.
+// Objects become structs with a field per property, so that dynamic
+// parsing has exactly the rules of typed parsing.
+func (s Schema) goType(path string) (reflect.Type, error) {
+	var t reflect.Type
+	switch s.Type {
+	case TypeAny:
+		return reflect.TypeOf((*interface{})(nil)).Elem(), nil
+	case TypeString:
+		t = reflect.TypeOf("")
+	case TypeInteger:
+		t = reflect.TypeOf(int64(0))
+	case TypeNumber:
+		t = reflect.TypeOf(float64(0))
+	case TypeBoolean:
+		t = reflect.TypeOf(false)
+	case TypeArray:
+		var items Schema
+		if s.Items != nil {
+			items = *s.Items
+		}
+		elem, err := items.goType(path + "[]")
+		if err != nil {
+			return nil, err
+		}
+		t = reflect.SliceOf(elem)
+	case TypeObject:
+		if len(s.Properties) == 0 {
+			t = reflect.TypeOf(map[string]interface{}{})
+			break
+		}
+		var err error
+		if t, err = s.structType(path); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, schemaPathError(path, fmt.Errorf("unknown type %q", s.Type))
+	}
+	if s.Enum != nil && s.Type != TypeString {
+		return nil, schemaPathError(path, fmt.Errorf("enum values require type string, not %q", s.Type))
+	}
+	if s.Nullable {
+		t = reflect.PointerTo(t)
+	}
+	return t, nil
+}
+
+// structType returns a struct type with a field for each property.
+func (s Schema) structType(path string) (reflect.Type, error) {
+	fields := make([]reflect.StructField, len(s.Properties))
+	seen := make(map[string]bool, len(s.Properties))
+	goNames := make(map[string]bool, len(s.Properties))
+	for i, prop := range s.Properties {
+		propPath := joinPath(path, prop.Name)
+		if prop.Name == "" || strings.Contains(prop.Name, ",") {
+			return nil, schemaPathError(path, fmt.Errorf("unsupported property name %q", prop.Name))
+		}
+		if seen[prop.Name] {
+			return nil, schemaPathError(path, fmt.Errorf("duplicate property %q", prop.Name))
+		}
+		seen[prop.Name] = true
+
+		ft, err := prop.Schema.goType(propPath)
+		if err != nil {
+			return nil, err
+		}
+		if prop.Optional && ft.Kind() != reflect.Ptr && ft.Kind() != reflect.Interface {
+			ft = reflect.PointerTo(ft)
+		}
+
+		name := prop.Name
+		if name == "-" {
+			name = "-,"
+		}
+		tag := "json:" + strconv.Quote(name)
+		var opts []string
+		if !prop.Optional && !prop.Schema.Nullable {
+			opts = append(opts, "required")
+		}
+		if len(prop.Schema.Enum) > 0 {
+			for _, v := range prop.Schema.Enum {
+				if strings.ContainsAny(v, "|'") || strings.TrimSpace(v) != v || v == "" {
+					return nil, schemaPathError(propPath, fmt.Errorf("unsupported enum value %q", v))
+				}
+			}
+			opts = append(opts, "enum='"+strings.Join(prop.Schema.Enum, "|")+"'")
+		}
+		if len(opts) > 0 {
+			tag += " gsap:" + strconv.Quote(strings.Join(opts, ","))
+		}
+		if prop.Schema.Description != "" {
+			tag += " description:" + strconv.Quote(prop.Schema.Description)
+		}
+
+		fields[i] = reflect.StructField{
+			Name: goFieldName(prop.Name, i, goNames),
+			Type: ft,
+			Tag:  reflect.StructTag(tag),
+		}
+	}
+	return reflect.StructOf(fields), nil
+}
+
+// goFieldName returns an exported Go name for property i, which is what
+// input keys are matched against case-insensitively, e.g. "Status" for
+// "status". Names that aren't identifiers, or that fold to the same name
+// as an earlier property, get a placeholder instead. taken holds the
+// folded names already used.
+func goFieldName(name string, i int, taken map[string]bool) string {
+	runes := []rune(name)
+	runes[0] = unicode.ToUpper(runes[0])
+	placeholder := "P" + strconv.Itoa(i) + "_"
+	if !unicode.IsUpper(runes[0]) {
+		return placeholder
+	}
+	for _, r := range runes {
+		if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return placeholder
+		}
+	}
+	folded := strings.ToLower(name)
+	if taken[folded] {
+		return placeholder
+	}
+	taken[folded] = true
+	return string(runes)
+}
+
+// normalize converts v, coerced to the schema's Go type, to the values
+// ParseDynamic returns. presence decides which optional properties were
+// in the input.
+func (s Schema) normalize(v reflect.Value, path string, presence map[string]FieldPresence) interface{} {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		out := make(map[string]interface{}, len(s.Properties))
+		for i, prop := range s.Properties {
+			propPath := joinPath(path, prop.Name)
+			if prop.Optional && presence[propPath] == FieldMissing {
+				continue
+			}
+			out[prop.Name] = prop.Schema.normalize(v.Field(i), propPath, presence)
+		}
+		return out
+	case reflect.Slice:
+		var items Schema
+		if s.Items != nil {
+			items = *s.Items
+		}
+		out := make([]interface{}, v.Len())
+		for i := range out {
+			out[i] = items.normalize(v.Index(i), path+"["+strconv.Itoa(i)+"]", presence)
+		}
+		return out
+	}
+	return v.Interface()
+}
+
+// schemaPathError reports err at path of a Schema.
+func schemaPathError(path string, err error) error {
+	if path == "" {
+		return err
+	}
+	return fmt.Errorf("%s: %w", path, err)
+}
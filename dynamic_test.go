@@ -0,0 +1,214 @@
+package sap
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var testTicketSchema = Schema{
+	Type: TypeObject,
+	Properties: []Property{
+		{Name: "title", Schema: Schema{Type: TypeString}},
+		{Name: "priority", Schema: Schema{Type: TypeString, Enum: []string{"low", "medium", "high"}}},
+		{Name: "estimate", Schema: Schema{Type: TypeInteger}},
+		{Name: "cost", Schema: Schema{Type: TypeNumber, Nullable: true}},
+		{Name: "urgent", Schema: Schema{Type: TypeBoolean}, Optional: true},
+		{Name: "tags", Schema: Schema{Type: TypeArray, Items: &Schema{Type: TypeString}}, Optional: true},
+		{Name: "assignee", Schema: Schema{Type: TypeObject, Properties: []Property{
+			{Name: "name", Schema: Schema{Type: TypeString}},
+			{Name: "team", Schema: Schema{Type: TypeString}, Optional: true},
+		}}, Optional: true},
+		{Name: "meta", Schema: Schema{Type: TypeObject}, Optional: true},
+	},
+}
+
+func TestParseDynamic(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+		flags []string
+	}{
+		{
+			name:  "clean",
+			input: `{"title": "Fix login", "priority": "high", "estimate": 3, "cost": 12.5, "urgent": true, "tags": ["auth"], "assignee": {"name": "Ann"}, "meta": {"k": 1}}`,
+			want:  `{"assignee":{"name":"Ann"},"cost":12.5,"estimate":3,"meta":{"k":1},"priority":"high","tags":["auth"],"title":"Fix login","urgent":true}`,
+		},
+		{
+			name: "messy",
+			input: "Here you go:\n```json\n" +
+				`{TITLE: 'Fix login', priority: "HIGH", estimate: "3 days", cost: "N/A", tags: "auth, ui",}` + "\n```",
+			want:  `{"cost":null,"estimate":3,"priority":"high","tags":["auth","ui"],"title":"Fix login"}`,
+			flags: []string{string(FlagFuzzyFieldMatch), string(FlagEnumCaseInsensitive), string(FlagUnitStripped), string(FlagCommaSplitToSlice)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, score, err := ParseDynamic(tt.input, testTicketSchema)
+			if err != nil {
+				t.Fatalf("ParseDynamic failed: %v", err)
+			}
+			data, _ := json.Marshal(got)
+			if string(data) != tt.want {
+				t.Errorf("got %s, want %s", data, tt.want)
+			}
+			for _, flag := range tt.flags {
+				if _, ok := score.Flags()[flag]; !ok {
+					t.Errorf("missing flag %s in %v", flag, score.Flags())
+				}
+			}
+			if tt.flags == nil && score.Total() != 0 {
+				t.Errorf("expected clean score, got %v", score.Flags())
+			}
+		})
+	}
+}
+
+func TestParseDynamicValueTypes(t *testing.T) {
+	got, _, err := ParseDynamic(`{"title": "a", "priority": "low", "estimate": 2.0, "cost": 1}`, testTicketSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := got["estimate"].(int64); !ok {
+		t.Errorf("expected int64 estimate, got %T", got["estimate"])
+	}
+	if _, ok := got["cost"].(float64); !ok {
+		t.Errorf("expected float64 cost, got %T", got["cost"])
+	}
+}
+
+func TestParseDynamicErrors(t *testing.T) {
+	got, _, err := ParseDynamic(`{"priority": "xyzzy", "estimate": 1, "cost": null}`, testTicketSchema)
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected *ParseError, got %v", err)
+	}
+	var paths []string
+	for _, fe := range perr.Errors {
+		paths = append(paths, fe.Path)
+	}
+	if strings.Join(paths, ",") != "title,priority" || !errors.Is(err, ErrMissingField) {
+		t.Errorf("unexpected errors: %v", err)
+	}
+	if got["estimate"] != int64(1) {
+		t.Errorf("expected partial result, got %v", got)
+	}
+
+	bad := []struct {
+		schema Schema
+		want   string
+	}{
+		{Schema{Type: TypeString}, "must describe an object"},
+		{Schema{Type: TypeObject, Properties: []Property{{Name: "a", Schema: Schema{Type: "date"}}}}, `a: unknown type "date"`},
+		{Schema{Type: TypeObject, Properties: []Property{{Name: "a", Schema: Schema{Type: TypeInteger, Enum: []string{"1"}}}}}, "enum values require type string"},
+		{Schema{Type: TypeObject, Properties: []Property{{Name: "a", Schema: Schema{Type: TypeString, Enum: []string{"x|y"}}}}}, "unsupported enum value"},
+		{Schema{Type: TypeObject, Properties: []Property{{Name: "a"}, {Name: "a"}}}, `duplicate property "a"`},
+	}
+	for _, tt := range bad {
+		if _, _, err := ParseDynamic(`{"a": 1}`, tt.schema); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("expected error containing %q, got %v", tt.want, err)
+		}
+	}
+}
+
+func TestParseDynamicPropertyNames(t *testing.T) {
+	schema := Schema{Type: TypeObject, Properties: []Property{
+		{Name: "first name", Schema: Schema{Type: TypeString}},
+		{Name: "Size", Schema: Schema{Type: TypeInteger}},
+		{Name: "size", Schema: Schema{Type: TypeInteger}},
+		{Name: "ünits", Schema: Schema{Type: TypeString}},
+	}}
+	got, _, err := ParseDynamic(`{"first name": "Ann", "Size": 1, "size": 2, "ÜNITS": "cm"}`, schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"first name": "Ann", "Size": int64(1), "size": int64(2), "ünits": "cm"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestSchemaFromJSON(t *testing.T) {
+	doc := []byte(`{
+		"type": "object",
+		"properties": {
+			"title": {"type": "string", "description": "Short summary"},
+			"priority": {"$ref": "#/$defs/priority"},
+			"estimate": {"type": ["integer", "null"]},
+			"owner": {"anyOf": [{"$ref": "#/$defs/person"}, {"type": "null"}]},
+			"labels": {"type": "array", "items": {"type": "string"}},
+			"extra": {}
+		},
+		"required": ["title", "priority"],
+		"$defs": {
+			"priority": {"enum": ["low", "high"]},
+			"person": {"type": "object", "properties": {"name": {"type": "string"}}, "required": ["name"]}
+		}
+	}`)
+	got, err := SchemaFromJSON(doc)
+	if err != nil {
+		t.Fatalf("SchemaFromJSON failed: %v", err)
+	}
+	want := Schema{Type: TypeObject, Properties: []Property{
+		{Name: "estimate", Schema: Schema{Type: TypeInteger, Nullable: true}, Optional: true},
+		{Name: "extra", Schema: Schema{}, Optional: true},
+		{Name: "labels", Schema: Schema{Type: TypeArray, Items: &Schema{Type: TypeString}}, Optional: true},
+		{Name: "owner", Schema: Schema{Type: TypeObject, Nullable: true, Properties: []Property{
+			{Name: "name", Schema: Schema{Type: TypeString}},
+		}}, Optional: true},
+		{Name: "priority", Schema: Schema{Type: TypeString, Enum: []string{"low", "high"}}},
+		{Name: "title", Schema: Schema{Type: TypeString, Description: "Short summary"}},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+
+	errs := []struct {
+		doc  string
+		want string
+	}{
+		{`{"type": ["string", "integer"]}`, "unions of several types"},
+		{`{"anyOf": [{"type": "string"}, {"type": "integer"}]}`, "unions of several types"},
+		{`{"$ref": "#/$defs/node", "$defs": {"node": {"type": "object", "properties": {"next": {"$ref": "#/$defs/node"}}}}}`, "recursive reference"},
+		{`{"enum": [1, 2]}`, "only string values"},
+		{`{"$ref": "#/$defs/missing"}`, "not found"},
+	}
+	for _, tt := range errs {
+		if _, err := SchemaFromJSON([]byte(tt.doc)); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.doc, tt.want, err)
+		}
+	}
+}
+
+// TestSchemaFromGeneratedJSON checks that a schema generated from a Go type
+// parses input like the type itself.
+func TestSchemaFromGeneratedJSON(t *testing.T) {
+	generated, err := JSONSchema[TestUser]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(generated)
+	schema, err := SchemaFromJSON(data)
+	if err != nil {
+		t.Fatalf("SchemaFromJSON failed: %v", err)
+	}
+
+	input := `{name: "Ann", age: "30 years", email: "ann@example.com"}`
+	user, typedScore, err := ParseWithScore[TestUser](input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, score, err := ParseDynamic(input, schema)
+	if err != nil {
+		t.Fatalf("ParseDynamic failed: %v", err)
+	}
+	if got["name"] != user.Name || got["age"] != int64(user.Age) || got["email"] != user.Email {
+		t.Errorf("got %v, want %+v", got, user)
+	}
+	if score.Total() != typedScore.Total() {
+		t.Errorf("got score %v, typed parse scored %v", score.Flags(), typedScore.Flags())
+	}
+}
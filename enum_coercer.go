@@ -2,50 +2,650 @@ package sap
 
 import (
 	"fmt"
+	"math"
 	"reflect"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"unicode"
+	"unicode/utf8"
 )
 
-// EnumCoercer handles coercion to enum types
-type EnumCoercer struct{}
+// enumDef describes the allowed values of an enum type.
+type enumDef struct {
+	names        []string        // canonical names, in declaration order
+	values       []reflect.Value // Go value for each name
+	descriptions []string        // optional description for each name
+	aliases      [][]string      // alternative spellings for each name
+	probed       bool            // names found by calling String
+	matches      []enumCandidate // candidates, if computed up front
+}
+
+// EnumValue describes one value of an enum type for RegisterEnumValues.
+type EnumValue[T any] struct {
+	Value       T
+	Description string   // What the value means; also accepted as input
+	Aliases     []string // Synonyms accepted in place of the value
+}
+
+// EnumMember describes one allowed value of an enum type, as returned by
+// EnumMembers.
+type EnumMember struct {
+	Name        string
+	Value       interface{}
+	Description string
+	Aliases     []string
+}
+
+var (
+	enumMu       sync.RWMutex
+	enumRegistry = make(map[reflect.Type]*enumDef)
+)
+
+// RegisterEnum declares the allowed values of the enum type T.
+//
+// Once registered, any field of type T (or *T, []T) is matched against
+// these values: exactly, then case-insensitively, then by Levenshtein
+// distance. Values that match nothing are rejected.
+//
+//	type Sentiment string
+//
+//	sap.RegisterEnum[Sentiment]("positive", "negative", "neutral")
+//
+// Registering the same type again replaces its previous values.
+func RegisterEnum[T any](values ...T) {
+	members := make([]EnumValue[T], len(values))
+	for i, v := range values {
+		members[i] = EnumValue[T]{Value: v}
+	}
+	RegisterEnumValues(members...)
+}
+
+// RegisterEnumValues is like RegisterEnum but also attaches a description
+// and aliases to each value. Aliases and descriptions are matched like the
+// value itself, so an LLM answering "thumbs up" or with the description
+// text still resolves to the right value:
+//
+//	sap.RegisterEnumValues(
+//	    sap.EnumValue[Sentiment]{Value: "positive", Aliases: []string{"thumbs up", "good"}},
+//	    sap.EnumValue[Sentiment]{Value: "negative", Description: "The customer is unhappy"},
+//	)
+func RegisterEnumValues[T any](values ...EnumValue[T]) {
+	enumType := reflect.TypeOf((*T)(nil)).Elem()
+
+	def := &enumDef{}
+	for _, v := range values {
+		rv := reflect.ValueOf(v.Value)
+		def.names = append(def.names, enumName(rv))
+		def.values = append(def.values, rv)
+		def.descriptions = append(def.descriptions, v.Description)
+		def.aliases = append(def.aliases, append([]string(nil), v.Aliases...))
+	}
+
+	enumMu.Lock()
+	enumRegistry[enumType] = def
+	enumMu.Unlock()
+}
+
+// EnumMembers returns the allowed values of enumType with their
+// descriptions and aliases, in declaration order. It covers enums declared
+// with RegisterEnum, RegisterEnumValues and RegisterStringerEnum and
+// integer types whose String method names their values, and returns nil
+// for any other type. Use it to render prompt instructions that stay in
+// sync with what the parser accepts.
+func EnumMembers(enumType reflect.Type) []EnumMember {
+	return lookupEnum(enumType).members()
+}
+
+// members returns the enum's values as EnumMembers.
+func (d *enumDef) members() []EnumMember {
+	if d == nil {
+		return nil
+	}
+	members := make([]EnumMember, len(d.names))
+	for i, name := range d.names {
+		members[i] = EnumMember{
+			Name:        name,
+			Value:       d.values[i].Interface(),
+			Description: d.description(i),
+			Aliases:     append([]string(nil), d.aliasesOf(i)...),
+		}
+	}
+	return members
+}
 
-// CoerceToEnum attempts to coerce a value to an enum type
+// description returns the description of the i-th value, if any.
+func (d *enumDef) description(i int) string {
+	if i < len(d.descriptions) {
+		return d.descriptions[i]
+	}
+	return ""
+}
+
+// aliasesOf returns the aliases of the i-th value, if any.
+func (d *enumDef) aliasesOf(i int) []string {
+	if i < len(d.aliases) {
+		return d.aliases[i]
+	}
+	return nil
+}
+
+// withAliases returns a copy of d with extra aliases, keyed by canonical
+// name, added to its values. Aliases for unknown names are ignored.
+func (d *enumDef) withAliases(extra map[string][]string) *enumDef {
+	if len(extra) == 0 {
+		return d
+	}
+	out := &enumDef{
+		probed:       d.probed,
+		names:        d.names,
+		values:       d.values,
+		descriptions: d.descriptions,
+		aliases:      make([][]string, len(d.names)),
+	}
+	for i, name := range d.names {
+		out.aliases[i] = append(append([]string(nil), d.aliasesOf(i)...), extra[name]...)
+	}
+	return out
+}
+
+// enumName returns the name an enum value is matched by.
+func enumName(v reflect.Value) string {
+	if v.Kind() == reflect.String {
+		return v.String()
+	}
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// stringerType is the reflect.Type of fmt.Stringer.
+var stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+
+// stringerEnums caches the names discovered for unregistered integer
+// types with a String method, including nil for those that aren't enums.
+var stringerEnums sync.Map // reflect.Type -> *enumDef
+
+// lookupEnum returns the definition for enumType: its registered values,
+// or for an unregistered integer type with a String method, the names
+// discoverStringerEnum finds. It returns nil if enumType is not an enum.
+func lookupEnum(enumType reflect.Type) *enumDef {
+	enumMu.RLock()
+	def := enumRegistry[enumType]
+	enumMu.RUnlock()
+	if def != nil || !isIntegerKind(enumType.Kind()) || !enumType.Implements(stringerType) {
+		return def
+	}
+
+	if cached, ok := stringerEnums.Load(enumType); ok {
+		return cached.(*enumDef)
+	}
+	def = discoverStringerEnum(enumType)
+	stringerEnums.Store(enumType, def)
+	return def
+}
+
+// isIntegerKind reports whether k is a signed or unsigned integer kind.
+func isIntegerKind(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Uint64 && k != reflect.Uintptr
+}
+
+// maxStringerProbe bounds the integer values RegisterStringerEnum probes
+// for names.
+const maxStringerProbe = 256
+
+// reStringerDefault matches the fallback output of stringer-generated code
+// for values outside the enum, e.g. "Priority(7)".
+var reStringerDefault = regexp.MustCompile(`^\w*\(-?\d+\)$`)
+
+// RegisterStringerEnum declares the integer enum type T, naming its values
+// by their String method the way stringer-generated and hand-written iota
+// enums do. Such types are detected the same way without registration;
+// registering one checks at startup that String names a fixed set of
+// values:
+//
+//	type Priority int
+//
+//	const (
+//		Low Priority = iota
+//		Medium
+//		High
+//	)
+//
+//	func (p Priority) String() string { return [...]string{"Low", "Medium", "High"}[p] }
+//
+//	sap.RegisterStringerEnum[Priority]()
+//
+// The names are found by calling String on 0 through 255. Values whose
+// String panics or returns a number, stringer's "Priority(7)" fallback or
+// a name shared with other values are skipped. Once registered, numbers in
+// that range that have no name are rejected; numbers outside it are
+// accepted as they are, since the probe can't tell whether they are valid.
+//
+// It panics if T is not an integer type, or if its String method names no
+// value, or names every value, which means it formats a quantity (like
+// time.Duration) rather than naming constants. Use RegisterEnum to list
+// the values explicitly instead.
+func RegisterStringerEnum[T fmt.Stringer]() {
+	enumType := reflect.TypeOf((*T)(nil)).Elem()
+	if !isIntegerKind(enumType.Kind()) {
+		panic(fmt.Sprintf("sap: RegisterStringerEnum requires an integer type, got %v", enumType))
+	}
+	def := discoverStringerEnum(enumType)
+	if def == nil {
+		panic(fmt.Sprintf("sap: RegisterStringerEnum[%v]: String names no fixed set of values", enumType))
+	}
+
+	enumMu.Lock()
+	enumRegistry[enumType] = def
+	enumMu.Unlock()
+}
+
+// discoverStringerEnum reverse-looks-up the names of an iota enum by
+// calling String on the values 0..maxStringerProbe. Names produced by more
+// than one value are treated as the String method's default case and
+// dropped, as are stringer's "Type(N)" fallbacks. It returns nil when no
+// names are found, or when every probed value has its own name.
+func discoverStringerEnum(enumType reflect.Type) *enumDef {
+	seen := make(map[string]int)
+	var names []string
+	var values []reflect.Value
+
+	for i := 0; i < maxStringerProbe; i++ {
+		v := reflect.New(enumType).Elem()
+		if v.Kind() >= reflect.Uint && v.Kind() <= reflect.Uint64 {
+			v.SetUint(uint64(i))
+		} else {
+			v.SetInt(int64(i))
+		}
+		name, ok := probeString(v)
+		if !ok || name == "" || name == strconv.Itoa(i) || reStringerDefault.MatchString(name) {
+			continue
+		}
+		seen[name]++
+		if seen[name] == 1 {
+			names = append(names, name)
+			values = append(values, v)
+		}
+	}
+
+	if len(names) == maxStringerProbe {
+		return nil
+	}
+
+	def := &enumDef{probed: true}
+	for i, name := range names {
+		if seen[name] == 1 {
+			def.names = append(def.names, name)
+			def.values = append(def.values, values[i])
+		}
+	}
+	if len(def.names) == 0 {
+		return nil
+	}
+	return def
+}
+
+// probeString calls String on v, reporting false if it panics, as String
+// methods indexing a table of names do for values past its end.
+func probeString(v reflect.Value) (name string, ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+	return v.Interface().(fmt.Stringer).String(), true
+}
+
+// enumDef returns the enum definition for a field of type enumType with
+// these tag options: the values from an enum= option, or the type's own
+// registered values, plus any aliases from an alias= option.
+// It returns nil if the field is not an enum.
+func (o fieldOptions) enumDef(enumType reflect.Type) *enumDef {
+	if len(o.enum) > 0 && enumType.Kind() == reflect.String {
+		return o.tagEnumDef(enumType)
+	}
+	def := lookupEnum(enumType)
+	if def == nil {
+		return nil
+	}
+	return def.withAliases(o.aliases)
+}
+
+// tagEnumDef returns the enum definition for string type enumType from
+// the enum= and alias= options. It depends only on the tag, so it is
+// built once per type and reused by every later coercion of the field.
+func (o fieldOptions) tagEnumDef(enumType reflect.Type) *enumDef {
+	if o.tagEnums != nil {
+		if def, ok := o.tagEnums.Load(enumType); ok {
+			return def.(*enumDef)
+		}
+	}
+
+	def := &enumDef{names: o.enum}
+	for _, name := range o.enum {
+		def.values = append(def.values, reflect.ValueOf(name).Convert(enumType))
+	}
+	def = def.withAliases(o.aliases)
+	def.matches = def.candidates()
+	if o.tagEnums == nil {
+		return def
+	}
+	cached, _ := o.tagEnums.LoadOrStore(enumType, def)
+	return cached.(*enumDef)
+}
+
+// CoerceToEnum attempts to coerce a value to an enum type.
+// Types without registered values are returned as their string form.
 func CoerceToEnum(value interface{}, enumType reflect.Type, score *Score) (interface{}, error) {
 	stringVal, err := coerceValueToString(value)
 	if err != nil {
 		return nil, err
 	}
 
-	// Get all possible enum values
-	enumValues := getEnumValues(enumType)
+	def := lookupEnum(enumType)
+	if def == nil || len(def.names) == 0 {
+		// Nothing to match against; let the caller decide what to do
+		return stringVal, nil
+	}
+
+	return def.match(stringVal, enumType, score)
+}
+
+// match resolves input to one of the enum's values.
+func (d *enumDef) match(input string, enumType reflect.Type, score *Score) (interface{}, error) {
+	i, ok := matchEnum(input, d.candidates(), score)
+	if !ok {
+		return nil, fmt.Errorf("cannot convert %q to %v: want one of %s",
+			input, enumType, strings.Join(d.names, ", "))
+	}
+	return d.values[i].Interface(), nil
+}
 
+// enumCandidate is a string that resolves to one of an enum's values.
+type enumCandidate struct {
+	text   string
+	member int       // index of the value the text resolves to
+	flag   ScoreFlag // extra flag for non-canonical text, "" for the name itself
+}
+
+// candidates returns every string that resolves to one of the enum's
+// values: the canonical names first, then aliases, then descriptions.
+func (d *enumDef) candidates() []enumCandidate {
+	if d.matches != nil {
+		return d.matches
+	}
+	var out []enumCandidate
+	for i, name := range d.names {
+		out = append(out, enumCandidate{text: name, member: i})
+	}
+	for i := range d.names {
+		for _, alias := range d.aliasesOf(i) {
+			out = append(out, enumCandidate{text: alias, member: i, flag: FlagEnumAliasMatch})
+		}
+	}
+	for i := range d.names {
+		if desc := d.description(i); desc != "" {
+			out = append(out, enumCandidate{text: desc, member: i, flag: FlagEnumDescriptionMatch})
+		}
+	}
+	return out
+}
+
+// nameCandidates returns enum candidates for a plain list of names.
+func nameCandidates(names []string) []enumCandidate {
+	out := make([]enumCandidate, len(names))
+	for i, name := range names {
+		out[i] = enumCandidate{text: name, member: i}
+	}
+	return out
+}
+
+// matchEnum finds the candidate that best matches input and returns the
+// index of the value it resolves to. It tries an exact match, then a
+// case-insensitive match, then a unique whole-word match, then a fuzzy
+// match, adding the corresponding penalty to score. The last two ignore
+// candidates that input negates with a word like "not". Matching an alias or
+// description adds its flag, plus a zero-valued "EnumAliasMatch:<alias>"
+// flag naming the text that matched.
+func matchEnum(input string, candidates []enumCandidate, score *Score) (int, bool) {
 	// Try exact match first
-	for _, ev := range enumValues {
-		if ev == stringVal {
-			return stringVal, nil
+	for _, c := range candidates {
+		if c.text == input {
+			return c.matched(score), true
+		}
+	}
+
+	trimmed := strings.TrimSpace(input)
+	if stripped, changed := stripMarkdown(trimmed); changed {
+		trimmed = stripped
+		score.AddFlag(FlagMarkdownStripped, 1)
+		for _, c := range candidates {
+			if c.text == trimmed {
+				return c.matched(score), true
+			}
 		}
 	}
 
 	// Try case-insensitive match
-	lowerVal := strings.ToLower(stringVal)
-	for _, ev := range enumValues {
-		if strings.ToLower(ev) == lowerVal {
-			score.AddFlag("EnumCaseInsensitive", 1)
-			return ev, nil
+	for _, c := range candidates {
+		if strings.EqualFold(c.text, trimmed) {
+			score.AddFlag(FlagEnumCaseInsensitive, 1)
+			return c.matched(score), true
 		}
 	}
 
+	// Looser matches skip candidates the input negates, since "not
+	// positive" doesn't mean positive
+	var unnegated []enumCandidate
+	for _, c := range candidates {
+		if !negates(trimmed, c.text) {
+			unnegated = append(unnegated, c)
+		}
+	}
+	candidates = unnegated
+
+	// Try a unique whole-word match, e.g. "high priority" for "High"
+	if c, ok := wordMatchEnum(trimmed, candidates); ok {
+		score.AddFlag(FlagEnumWordMatch, 1)
+		return c.matched(score), true
+	}
+
 	// Try fuzzy match with Unicode normalization
-	bestMatch := fuzzyMatchEnum(stringVal, enumValues)
-	if bestMatch != "" {
-		score.AddFlag("EnumFuzzyMatch", 2)
-		return bestMatch, nil
+	texts := make([]string, len(candidates))
+	for i, c := range candidates {
+		texts[i] = c.text
+	}
+	if best := fuzzyMatchEnum(trimmed, texts); best != "" {
+		for _, c := range candidates {
+			if c.text == best {
+				score.AddFlag(FlagEnumFuzzyMatch, 2)
+				return c.matched(score), true
+			}
+		}
+	}
+
+	return 0, false
+}
+
+// matched records which alias or description matched and returns the
+// index of the value the candidate resolves to.
+func (c enumCandidate) matched(score *Score) int {
+	if c.flag != "" {
+		score.AddFlag(c.flag, 1)
+		score.AddFlag(FlagEnumAliasMatch+":"+c.text, 0)
+	}
+	return c.member
+}
+
+// negationWords turn a phrase mentioning an enum value into one that may
+// mean the opposite, as in "not positive". Apostrophes are gone after
+// splitWords, so "isn't" is "isnt".
+var negationWords = map[string]bool{
+	"not": true, "no": true, "non": true, "never": true, "without": true,
+	"neither": true, "nor": true, "isnt": true, "arent": true, "wasnt": true,
+	"dont": true, "doesnt": true, "cant": true, "wont": true,
+}
+
+// wordMatchEnum returns the candidate that occurs as a whole word (or run
+// of words) in input. It fails if no candidate occurs, or if candidates
+// for more than one value occur.
+func wordMatchEnum(input string, candidates []enumCandidate) (enumCandidate, bool) {
+	var match enumCandidate
+	found := false
+	for _, c := range candidates {
+		if countWordOccurrences(input, c.text) == 0 {
+			continue
+		}
+		if found && match.member != c.member {
+			return enumCandidate{}, false
+		}
+		if !found {
+			match = c
+			found = true
+		}
+	}
+	return match, found
+}
+
+// negates reports whether input has a negation word that phrase doesn't.
+func negates(input, phrase string) bool {
+	own := make(map[string]bool)
+	for _, w := range splitWords(phrase) {
+		own[w] = true
+	}
+	for _, w := range splitWords(input) {
+		if negationWords[w] && !own[w] {
+			return true
+		}
+	}
+	return false
+}
+
+// countWordOccurrences counts how often phrase occurs in text as a whole
+// word sequence, ignoring case, accents, punctuation and the difference
+// between spaces, underscores and hyphens.
+func countWordOccurrences(text, phrase string) int {
+	textWords := splitWords(text)
+	phraseWords := splitWords(phrase)
+	if len(phraseWords) == 0 || len(phraseWords) > len(textWords) {
+		return 0
+	}
+
+	count := 0
+	for i := 0; i+len(phraseWords) <= len(textWords); i++ {
+		matched := true
+		for j, w := range phraseWords {
+			if textWords[i+j] != w {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			count++
+		}
+	}
+	return count
+}
+
+// splitWords normalizes s and splits it into words.
+func splitWords(s string) []string {
+	s = strings.NewReplacer("_", " ", "-", " ").Replace(s)
+	return strings.Fields(normalizeString(s))
+}
+
+// coerceToEnum converts value to a value of the enum described by def.
+func (c *TypeCoercer) coerceToEnum(value interface{}, targetType reflect.Type, def *enumDef, score *Score) (interface{}, error) {
+	stringVal, err := coerceValueToString(value)
+	if err != nil {
+		return nil, fmt.Errorf("cannot convert %T to %v", value, targetType)
+	}
+
+	result, err := def.match(stringVal, targetType, score)
+	if err != nil {
+		// An unmatched null-like string means the value is missing
+		if isNullString(stringVal) {
+			score.AddFlag(FlagNullStringCoerced, 1)
+			return reflect.Zero(targetType).Interface(), nil
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
+// coerceToIntEnum converts value to an integer enum. Numbers are accepted
+// if they are one of the enum's values, or lie outside the range the
+// String probe covered; strings are matched by name.
+func (c *TypeCoercer) coerceToIntEnum(value interface{}, targetType reflect.Type, def *enumDef, score *Score) (interface{}, error) {
+	var number float64
+
+	switch v := value.(type) {
+	case float64:
+		number = v
+
+	case string:
+		parsed, err := parseNumber(v)
+		if err != nil {
+			// Not a number, so resolve it by name
+			if i, ok := matchEnum(v, def.candidates(), score); ok {
+				score.AddFlag(FlagIntEnumFromName, 1)
+				return def.values[i].Convert(targetType).Interface(), nil
+			}
+			if isNullString(v) {
+				score.AddFlag(FlagNullStringCoerced, 1)
+				return reflect.Zero(targetType).Interface(), nil
+			}
+			return nil, fmt.Errorf("cannot convert %q to %v: want one of %s",
+				v, targetType, strings.Join(def.names, ", "))
+		}
+		number = parsed
+		score.AddFlag(FlagStringToInt, 2)
+
+	default:
+		return nil, fmt.Errorf("cannot convert %T to %v", value, targetType)
+	}
+
+	for _, ev := range def.values {
+		if enumValueEquals(ev, number) {
+			score.AddFlag(FlagIntEnumFromNumber, 0)
+			return ev.Convert(targetType).Interface(), nil
+		}
+	}
+	if def.probed && (number < 0 || number >= maxStringerProbe) {
+		// The probe can't tell whether an unnamed number is valid
+		if targetType.Kind() >= reflect.Uint && targetType.Kind() <= reflect.Uint64 {
+			return c.coerceToUint(number, targetType, score)
+		}
+		return c.coerceToInt(number, targetType, score)
+	}
+	return nil, fmt.Errorf("%v is not a valid %v: want one of %s",
+		number, targetType, strings.Join(def.names, ", "))
+}
+
+// enumValueEquals reports whether the integer enum value v equals number.
+func enumValueEquals(v reflect.Value, number float64) bool {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()) == number
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()) == number
 	}
+	return false
+}
 
-	// If still no match, return the original string
-	// (Go's json.Unmarshal will handle the actual type assertion)
-	return stringVal, nil
+// coerceToTaggedEnum coerces a value of an enum type using the enum= and
+// alias= options from a field's tag.
+func (c *TypeCoercer) coerceToTaggedEnum(value interface{}, targetType reflect.Type, opts fieldOptions, score *Score) (interface{}, error) {
+	def := opts.enumDef(targetType)
+	if def == nil {
+		return c.coerceValue(value, targetType, score)
+	}
+	if targetType.Kind() == reflect.String {
+		return c.coerceToEnum(value, targetType, def, score)
+	}
+	return c.coerceToIntEnum(value, targetType, def, score)
 }
 
 // coerceValueToString is a helper to convert any value to string
@@ -68,49 +668,41 @@ func coerceValueToString(value interface{}) (string, error) {
 	}
 }
 
-// getEnumValues extracts possible enum values
-// For Go types, we need reflection to find constants
-func getEnumValues(enumType reflect.Type) []string {
-	// In Go, enums are typically string constants
-	// This is a simplified version - in reality you'd want to use
-	// struct tags or a registry to define enum values
-
-	var values []string
-	// For now, return empty - the caller should populate this
-	return values
-}
+// Fuzzy enum matches may differ from the value they match by at most
+// fuzzyMaxRatio of the longer string's length, rounded, and never by more
+// than fuzzyMaxEdits, so short strings like "xyz" don't match "red" and long
+// ones don't match unrelated text of similar length.
+const (
+	fuzzyMaxRatio = 0.3
+	fuzzyMaxEdits = 3
+)
 
-// fuzzyMatchEnum attempts to match a string to enum values with fuzzy matching
+// fuzzyMatchEnum returns the enum value closest to input by edit distance,
+// ignoring case and accents, or "" if none is close enough.
 func fuzzyMatchEnum(input string, enumValues []string) string {
-	type scoreResult struct {
-		value string
-		score int
-	}
-
-	var results []scoreResult
-
+	best, bestDistance := "", -1
 	for _, enumValue := range enumValues {
-		score := stringDistance(input, enumValue)
-		results = append(results, scoreResult{enumValue, score})
-	}
-
-	// Return the best match if it's close enough
-	if len(results) > 0 {
-		best := results[0]
-		for _, r := range results {
-			if r.score < best.score {
-				best = r
-			}
-		}
-
-		// Only return if reasonably close match (< 50% different)
-		threshold := (len(input) + len(best.value)) / 2
-		if best.score <= threshold {
-			return best.value
+		distance := stringDistance(input, enumValue)
+		if bestDistance < 0 || distance < bestDistance {
+			best, bestDistance = enumValue, distance
 		}
 	}
+	if bestDistance < 0 {
+		return ""
+	}
 
-	return ""
+	longer := utf8.RuneCountInString(normalizeString(input))
+	if n := utf8.RuneCountInString(normalizeString(best)); n > longer {
+		longer = n
+	}
+	allowed := int(math.Round(fuzzyMaxRatio * float64(longer)))
+	if allowed > fuzzyMaxEdits {
+		allowed = fuzzyMaxEdits
+	}
+	if bestDistance > allowed {
+		return ""
+	}
+	return best
 }
 
 // stringDistance calculates Levenshtein distance with normalization
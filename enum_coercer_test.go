@@ -2,7 +2,9 @@ package sap
 
 import (
 	"reflect"
+	"strconv"
 	"testing"
+	"time"
 )
 
 // ---------------------------------------------------------------------------
@@ -344,6 +346,30 @@ func TestFuzzyMatchEnum(t *testing.T) {
 			enumValues: []string{"Canceled", "Active"},
 			want:       "",
 		},
+		{
+			name:       "short unrelated word",
+			input:      "xyz",
+			enumValues: []string{"red", "green", "blue"},
+			want:       "",
+		},
+		{
+			name:       "unrelated words of similar length",
+			input:      "banana",
+			enumValues: []string{"positive", "negative", "neutral"},
+			want:       "",
+		},
+		{
+			name:       "unrelated phrase",
+			input:      "hello world",
+			enumValues: []string{"positive", "negative", "neutral"},
+			want:       "",
+		},
+		{
+			name:       "transposed letters",
+			input:      "Meduim",
+			enumValues: []string{"Low", "Medium", "High"},
+			want:       "Medium",
+		},
 		{
 			name:       "empty enum values",
 			input:      "anything",
@@ -379,9 +405,9 @@ func TestFuzzyMatchEnum(t *testing.T) {
 // ---------------------------------------------------------------------------
 
 func TestCoerceToEnum(t *testing.T) {
-	// getEnumValues returns an empty slice, so CoerceToEnum always falls
-	// through to returning the stringified input. We verify it doesn't
-	// panic and returns the expected stringified value.
+	// string has no registered values, so CoerceToEnum falls through to
+	// returning the stringified input. We verify it doesn't panic and
+	// returns the expected stringified value.
 	enumType := reflect.TypeOf("")
 
 	tests := []struct {
@@ -469,3 +495,498 @@ func TestCoerceToEnumScoreNotMutated(t *testing.T) {
 		t.Errorf("expected score total to remain 5, got %d", score.Total())
 	}
 }
+
+// ---------------------------------------------------------------------------
+// RegisterEnum / enum tags
+// ---------------------------------------------------------------------------
+
+type testSentiment string
+
+type testReview struct {
+	Text      string         `json:"text"`
+	Sentiment testSentiment  `json:"sentiment"`
+	Previous  *testSentiment `json:"previous"`
+}
+
+type testTicket struct {
+	Title    string   `json:"title"`
+	Status   string   `json:"status" gsap:"enum=open|in_progress|closed"`
+	Severity *string  `json:"severity" gsap:"enum=low|high"`
+	Labels   []string `json:"labels" gsap:"enum=bug|feature|docs"`
+}
+
+func init() {
+	RegisterEnum[testSentiment]("positive", "negative", "neutral")
+}
+
+func TestRegisteredEnumMatching(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		want     testSentiment
+		wantFlag string
+	}{
+		{"exact", "positive", "positive", ""},
+		{"case-insensitive", "NEGATIVE", "negative", FlagEnumCaseInsensitive},
+		{"fuzzy typo", "nuetral", "neutral", FlagEnumFuzzyMatch},
+		{"markdown stripped", "**neutral**", "neutral", FlagMarkdownStripped},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := `{"text": "ok", "sentiment": "` + tt.input + `"}`
+			review, score, err := ParseWithScore[testReview](input)
+			if err != nil {
+				t.Fatalf("ParseWithScore failed: %v", err)
+			}
+			if review.Sentiment != tt.want {
+				t.Errorf("Sentiment = %q, want %q", review.Sentiment, tt.want)
+			}
+			flags := score.Flags()
+			if tt.wantFlag == "" {
+				if score.Total() != 0 {
+					t.Errorf("expected zero score, got %d (flags: %v)", score.Total(), flags)
+				}
+			} else if _, ok := flags[tt.wantFlag]; !ok {
+				t.Errorf("expected %s flag, got %v", tt.wantFlag, flags)
+			}
+		})
+	}
+}
+
+func TestRegisteredEnumRejectsUnknownValue(t *testing.T) {
+	c := NewTypeCoercer()
+	_, _, err := c.Coerce("xylophone-orchestra", reflect.TypeOf(testSentiment("")))
+	if err == nil {
+		t.Fatal("expected error for value matching no enum member")
+	}
+}
+
+func TestRegisteredEnumPointer(t *testing.T) {
+	review, err := Parse[testReview](`{"text": "ok", "sentiment": "positive", "previous": "Neutral"}`)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if review.Previous == nil || *review.Previous != "neutral" {
+		t.Errorf("Previous = %v, want neutral", review.Previous)
+	}
+
+	review, err = Parse[testReview](`{"text": "ok", "sentiment": "positive", "previous": "N/A"}`)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if review.Previous != nil {
+		t.Errorf("Previous = %v, want nil", *review.Previous)
+	}
+}
+
+func TestCoerceToEnumRegistered(t *testing.T) {
+	score := newScore()
+	got, err := CoerceToEnum("Positive", reflect.TypeOf(testSentiment("")), score)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != testSentiment("positive") {
+		t.Errorf("got %#v, want testSentiment(\"positive\")", got)
+	}
+	if _, ok := score.Flags()[FlagEnumCaseInsensitive]; !ok {
+		t.Errorf("expected %s flag, got %v", FlagEnumCaseInsensitive, score.Flags())
+	}
+
+	if _, err := CoerceToEnum("zzzzzzzzzzzzz", reflect.TypeOf(testSentiment("")), newScore()); err == nil {
+		t.Error("expected error for unmatched value")
+	}
+}
+
+func TestTaggedEnumField(t *testing.T) {
+	input := `{"title": "Crash", "status": "In Progress", "severity": "HIGH", "labels": "Bug, docs"}`
+
+	ticket, err := Parse[testTicket](input)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if ticket.Status != "in_progress" {
+		t.Errorf("Status = %q, want in_progress", ticket.Status)
+	}
+	if ticket.Severity == nil || *ticket.Severity != "high" {
+		t.Errorf("Severity = %v, want high", ticket.Severity)
+	}
+	if len(ticket.Labels) != 2 || ticket.Labels[0] != "bug" || ticket.Labels[1] != "docs" {
+		t.Errorf("Labels = %v, want [bug docs]", ticket.Labels)
+	}
+}
+
+func TestLookupEnumNames(t *testing.T) {
+	got := lookupEnum(reflect.TypeOf(testSentiment(""))).names
+	want := []string{"positive", "negative", "neutral"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("names = %v, want %v", got, want)
+	}
+	if def := lookupEnum(reflect.TypeOf("")); def != nil {
+		t.Errorf("lookupEnum(string) = %v, want nil", def)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Integer enums
+// ---------------------------------------------------------------------------
+
+type testPriority int
+
+const (
+	testPriorityLow testPriority = iota
+	testPriorityMedium
+	testPriorityHigh
+)
+
+// String mimics stringer-generated code, including its fallback.
+func (p testPriority) String() string {
+	switch p {
+	case testPriorityLow:
+		return "Low"
+	case testPriorityMedium:
+		return "Medium"
+	case testPriorityHigh:
+		return "High"
+	}
+	return "testPriority(" + strconv.Itoa(int(p)) + ")"
+}
+
+type testLevel uint8
+
+// String has a hand-written default case shared by every unknown value.
+func (l testLevel) String() string {
+	switch l {
+	case 1:
+		return "debug"
+	case 2:
+		return "info"
+	case 3:
+		return "error"
+	}
+	return "unknown"
+}
+
+type testTask struct {
+	Title    string        `json:"title"`
+	Priority testPriority  `json:"priority"`
+	Level    testLevel     `json:"level"`
+	Timeout  time.Duration `json:"timeout"`
+}
+
+func TestIntEnumByName(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		want      testPriority
+		wantFlags []string
+	}{
+		{"exact name", `"High"`, testPriorityHigh, []string{FlagIntEnumFromName}},
+		{"lowercase name", `"medium"`, testPriorityMedium, []string{FlagIntEnumFromName, FlagEnumCaseInsensitive}},
+		{"name in phrase", `"high priority"`, testPriorityHigh, []string{FlagIntEnumFromName, FlagEnumWordMatch}},
+		{"typo", `"Meduim"`, testPriorityMedium, []string{FlagIntEnumFromName, FlagEnumFuzzyMatch}},
+		{"number", `2`, testPriorityHigh, []string{FlagIntEnumFromNumber}},
+		{"numeric string", `"1"`, testPriorityMedium, []string{FlagIntEnumFromNumber, FlagStringToInt}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := `{"title": "t", "priority": ` + tt.input + `}`
+			task, score, err := ParseWithScore[testTask](input)
+			if err != nil {
+				t.Fatalf("ParseWithScore failed: %v", err)
+			}
+			if task.Priority != tt.want {
+				t.Errorf("Priority = %v, want %v", task.Priority, tt.want)
+			}
+			flags := score.Flags()
+			for _, f := range tt.wantFlags {
+				if _, ok := flags[f]; !ok {
+					t.Errorf("expected %s flag, got %v", f, flags)
+				}
+			}
+		})
+	}
+}
+
+func TestIntEnumRejectsUnknown(t *testing.T) {
+	c := NewTypeCoercer()
+	for _, input := range []interface{}{float64(7), "Critical Blocker Emergency"} {
+		if _, _, err := c.Coerce(input, reflect.TypeOf(testPriority(0))); err == nil {
+			t.Errorf("expected error for %v", input)
+		}
+	}
+}
+
+func TestIntEnumDefaultCaseDropped(t *testing.T) {
+	want := []string{"debug", "info", "error"}
+	if got := lookupEnum(reflect.TypeOf(testLevel(0))).names; !reflect.DeepEqual(got, want) {
+		t.Errorf("names = %v, want %v", got, want)
+	}
+
+	task, err := Parse[testTask](`{"title": "t", "level": "INFO"}`)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if task.Level != 2 {
+		t.Errorf("Level = %v, want info", task.Level)
+	}
+}
+
+// testSeverity indexes a table of names, so String panics past its end.
+type testSeverity int
+
+func (s testSeverity) String() string {
+	return [...]string{"minor", "major", "critical"}[s]
+}
+
+// testStatusCode names values well beyond the range probed for names.
+type testStatusCode int
+
+func (c testStatusCode) String() string {
+	switch c {
+	case 200:
+		return "OK"
+	case 204:
+		return "No Content"
+	case 404:
+		return "Not Found"
+	}
+	return ""
+}
+
+func TestRegisterStringerEnum(t *testing.T) {
+	RegisterStringerEnum[testSeverity]()
+	RegisterStringerEnum[testStatusCode]()
+
+	type incident struct {
+		Severity testSeverity   `json:"severity"`
+		Status   testStatusCode `json:"status"`
+	}
+	tests := []struct {
+		input   string
+		want    incident
+		wantErr bool
+	}{
+		{input: `{"severity": "Critical", "status": "No Content"}`, want: incident{2, 204}},
+		{input: `{"severity": 1, "status": 200}`, want: incident{1, 200}},
+		{input: `{"status": 404}`, want: incident{0, 404}},
+		{input: `{"severity": 3}`, wantErr: true},
+		{input: `{"status": 201}`, wantErr: true},
+		{input: `{"status": "Not Found"}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := Parse[incident](tt.input)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected error, got %+v", tt.input, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: Parse failed: %v", tt.input, err)
+		} else if got != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestRegisterStringerEnumPanics(t *testing.T) {
+	for name, register := range map[string]func(){
+		"quantity":    RegisterStringerEnum[time.Duration],
+		"not integer": RegisterStringerEnum[time.Time],
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: expected panic", name)
+				}
+			}()
+			register()
+		}()
+	}
+}
+
+func TestStringerEnumDetection(t *testing.T) {
+	if def := lookupEnum(reflect.TypeOf(time.Duration(0))); def != nil {
+		t.Fatalf("time.Duration should not be treated as an enum, got %v", def.names)
+	}
+
+	// Unregistered Stringer enums resolve names, survive a String method
+	// that panics past its table, and accept numbers the probe didn't cover
+	type review struct {
+		Severity testUnregisteredSeverity `json:"severity"`
+	}
+	tests := []struct {
+		input string
+		want  testUnregisteredSeverity
+	}{
+		{`{"severity": "Major"}`, 1},
+		{`{"severity": "critical issue"}`, 2},
+		{`{"severity": 0}`, 0},
+		{`{"severity": 1000}`, 1000},
+	}
+	for _, tt := range tests {
+		got, err := Parse[review](tt.input)
+		if err != nil || got.Severity != tt.want {
+			t.Errorf("%s: got %+v, %v; want severity %d", tt.input, got, err, tt.want)
+		}
+	}
+	for _, input := range []string{`{"severity": "catastrophic"}`, `{"severity": 7}`} {
+		if _, err := Parse[review](input); err == nil {
+			t.Errorf("%s: expected an error", input)
+		}
+	}
+
+	task, err := Parse[testTask](`{"title": "t", "timeout": 5000000000}`)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if task.Timeout != 5*time.Second {
+		t.Errorf("Timeout = %v, want 5s", task.Timeout)
+	}
+}
+
+type testUnregisteredSeverity int
+
+func (s testUnregisteredSeverity) String() string {
+	return [...]string{"minor", "major", "critical"}[s]
+}
+
+func TestIntEnumRegisteredValues(t *testing.T) {
+	type bitFlag int
+	RegisterEnum[bitFlag](1, 2, 4)
+
+	c := NewTypeCoercer()
+	got, _, err := c.Coerce(float64(4), reflect.TypeOf(bitFlag(0)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != bitFlag(4) {
+		t.Errorf("got %v, want 4", got)
+	}
+	if _, _, err := c.Coerce(float64(3), reflect.TypeOf(bitFlag(0))); err == nil {
+		t.Error("expected error for unregistered value 3")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Aliases and descriptions
+// ---------------------------------------------------------------------------
+
+type testMood string
+
+type testFeedback struct {
+	Mood     testMood     `json:"mood"`
+	Urgency  testPriority `json:"urgency" gsap:"alias=urgent:High|whenever:Low"`
+	Channel  string       `json:"channel" gsap:"enum=email|phone,alias=e-mail:email|call:phone"`
+	Comments string       `json:"comments"`
+}
+
+func init() {
+	RegisterEnumValues(
+		EnumValue[testMood]{Value: "positive", Aliases: []string{"thumbs up", "good"}},
+		EnumValue[testMood]{Value: "negative", Description: "The customer is unhappy"},
+		EnumValue[testMood]{Value: "neutral"},
+	)
+}
+
+func TestEnumAliasMatching(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		want      testMood
+		wantFlags []string
+	}{
+		{"alias", "thumbs up", "positive", []string{FlagEnumAliasMatch, FlagEnumAliasMatch + ":thumbs up"}},
+		{"alias case-insensitive", "Good", "positive", []string{FlagEnumAliasMatch, FlagEnumCaseInsensitive}},
+		{"description", "the customer is unhappy", "negative", []string{FlagEnumDescriptionMatch}},
+		{"canonical name", "neutral", "neutral", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fb, score, err := ParseWithScore[testFeedback](`{"mood": "` + tt.input + `", "urgency": 0, "channel": "email"}`)
+			if err != nil {
+				t.Fatalf("ParseWithScore failed: %v", err)
+			}
+			if fb.Mood != tt.want {
+				t.Errorf("Mood = %q, want %q", fb.Mood, tt.want)
+			}
+			flags := score.Flags()
+			for _, f := range tt.wantFlags {
+				if _, ok := flags[f]; !ok {
+					t.Errorf("expected %s flag, got %v", f, flags)
+				}
+			}
+			if tt.wantFlags == nil && score.Total() != 0 {
+				t.Errorf("expected zero score, got %v", flags)
+			}
+		})
+	}
+}
+
+func TestEnumWordMatchNegation(t *testing.T) {
+	for _, input := range []string{"not positive", "isn't good", "no thumbs up"} {
+		if fb, err := Parse[testFeedback](`{"mood": "` + input + `"}`); err == nil {
+			t.Errorf("%q: expected error, got %q", input, fb.Mood)
+		}
+	}
+
+	fb, err := Parse[testFeedback](`{"mood": "mostly positive"}`)
+	if err != nil || fb.Mood != "positive" {
+		t.Errorf("got %q, %v; want positive", fb.Mood, err)
+	}
+
+	type check struct {
+		Result string `json:"result" gsap:"enum=applicable|not applicable"`
+	}
+	c, err := Parse[check](`{"result": "not applicable here"}`)
+	if err != nil || c.Result != "not applicable" {
+		t.Errorf("got %q, %v; want not applicable", c.Result, err)
+	}
+}
+
+func TestEnumAliasTag(t *testing.T) {
+	fb, score, err := ParseWithScore[testFeedback](`{"mood": "good", "urgency": "urgent", "channel": "call"}`)
+	if err != nil {
+		t.Fatalf("ParseWithScore failed: %v", err)
+	}
+	if fb.Urgency != testPriorityHigh {
+		t.Errorf("Urgency = %v, want High", fb.Urgency)
+	}
+	if fb.Channel != "phone" {
+		t.Errorf("Channel = %q, want phone", fb.Channel)
+	}
+	flags := score.Flags()
+	for _, f := range []string{FlagEnumAliasMatch + ":urgent", FlagEnumAliasMatch + ":call"} {
+		if _, ok := flags[f]; !ok {
+			t.Errorf("expected %s flag, got %v", f, flags)
+		}
+	}
+}
+
+func TestEnumMembers(t *testing.T) {
+	members := EnumMembers(reflect.TypeOf(testMood("")))
+	if len(members) != 3 {
+		t.Fatalf("expected 3 members, got %d", len(members))
+	}
+	if members[0].Name != "positive" || !reflect.DeepEqual(members[0].Aliases, []string{"thumbs up", "good"}) {
+		t.Errorf("unexpected first member: %+v", members[0])
+	}
+	if members[1].Description != "The customer is unhappy" {
+		t.Errorf("unexpected second member: %+v", members[1])
+	}
+	if members[0].Value != testMood("positive") {
+		t.Errorf("Value = %#v, want testMood(\"positive\")", members[0].Value)
+	}
+
+	priorities := EnumMembers(reflect.TypeOf(testPriority(0)))
+	if len(priorities) != 3 || priorities[2].Name != "High" || priorities[2].Value != testPriorityHigh {
+		t.Errorf("unexpected Stringer members: %+v", priorities)
+	}
+
+	if got := EnumMembers(reflect.TypeOf("")); got != nil {
+		t.Errorf("EnumMembers(string) = %v, want nil", got)
+	}
+}
@@ -0,0 +1,96 @@
+package sap
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Sentinel errors for use with errors.Is.
+var (
+	// ErrNoJSON means the input contained no JSON to parse.
+	ErrNoJSON = errors.New("no JSON found in input")
+	// ErrCoercion means a value could not be coerced to its target type.
+	// Every FieldError matches it.
+	ErrCoercion = errors.New("coercion failed")
+	// ErrAmbiguous means the input matched several enum values or union
+	// variants equally well.
+	ErrAmbiguous = errors.New("ambiguous")
+	// ErrMissingField means a required struct field was absent or null.
+	ErrMissingField = errors.New("missing required field")
+	// ErrConstraint means a value broke a min, max, len, pattern or format
+	// constraint from its field's `gsap` tag.
+	ErrConstraint = errors.New("constraint violated")
+	// ErrValidation means a Validate method rejected a parsed value.
+	ErrValidation = errors.New("validation failed")
+	// ErrSchema means a value broke the JSON Schema passed to
+	// ValidateAgainstSchema or WithJSONSchema.
+	ErrSchema = errors.New("schema violated")
+	// ErrMaxScore means every candidate needed more coercion than the
+	// parser's WithMaxScore limit allows.
+	ErrMaxScore = errors.New("parse score exceeds maximum")
+)
+
+// FieldError describes a value that could not be coerced to its target type.
+type FieldError struct {
+	Path   string       // Location of the value, e.g. "employees[2].age"; "" for the root
+	Raw    interface{}  // The input value that failed
+	Target reflect.Type // The type it was coerced to
+	Cause  error        // Why coercion failed
+}
+
+// Error implements the error interface.
+func (e *FieldError) Error() string {
+	if e.Path == "" {
+		return e.Cause.Error()
+	}
+	return e.Path + ": " + e.Cause.Error()
+}
+
+// Unwrap returns the underlying cause.
+func (e *FieldError) Unwrap() error {
+	return e.Cause
+}
+
+// Is reports whether target is ErrCoercion.
+func (e *FieldError) Is(target error) bool {
+	return target == ErrCoercion
+}
+
+// ParseError collects every field that failed during a parse. It is
+// returned alongside the best partial result, in which failed fields keep
+// their zero value, so callers can decide whether to accept it:
+//
+//	user, err := sap.Parse[User](input)
+//	var perr *sap.ParseError
+//	if errors.As(err, &perr) {
+//	    for _, fe := range perr.Errors {
+//	        log.Printf("%s: %v", fe.Path, fe.Cause)
+//	    }
+//	}
+type ParseError struct {
+	Errors []FieldError
+}
+
+// Error implements the error interface.
+func (e *ParseError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i := range e.Errors {
+		msgs[i] = e.Errors[i].Error()
+	}
+	if len(msgs) == 1 {
+		return "failed to parse: " + msgs[0]
+	}
+	return fmt.Sprintf("failed to parse: %d errors: %s", len(msgs), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns each FieldError, so errors.Is and errors.As see through
+// a ParseError to the individual failures.
+func (e *ParseError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i := range e.Errors {
+		errs[i] = &e.Errors[i]
+	}
+	return errs
+}
@@ -0,0 +1,168 @@
+package sap
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testEmployee struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+type testDepartment struct {
+	Title     string         `json:"title"`
+	Budget    float64        `json:"budget"`
+	Employees []testEmployee `json:"employees"`
+	Scores    []int          `json:"scores"`
+}
+
+func TestParseErrorCollectsAllFields(t *testing.T) {
+	input := `{
+		"title": "R&D",
+		"budget": "lots",
+		"employees": [
+			{"name": "Ann", "age": 30},
+			{"name": "Bob", "age": 41},
+			{"name": "Cy", "age": "unknown-ish"}
+		],
+		"scores": [1, "two", 3]
+	}`
+
+	dept, err := Parse[testDepartment](input)
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected *ParseError, got %v", err)
+	}
+
+	paths := make([]string, len(perr.Errors))
+	for i, fe := range perr.Errors {
+		paths[i] = fe.Path
+	}
+	want := []string{"budget", "employees[2].age", "scores[1]"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("paths = %v, want %v", paths, want)
+	}
+
+	// The partial result keeps everything that did coerce
+	if dept.Title != "R&D" {
+		t.Errorf("Title = %q, want R&D", dept.Title)
+	}
+	if len(dept.Employees) != 3 || dept.Employees[1].Age != 41 || dept.Employees[2].Name != "Cy" {
+		t.Errorf("Employees = %+v", dept.Employees)
+	}
+	if dept.Scores != nil {
+		t.Errorf("Scores = %v, want nil after element failure", dept.Scores)
+	}
+}
+
+func TestFieldErrorDetails(t *testing.T) {
+	_, err := Parse[testEmployee](`{"name": "Ann", "age": "old"}`)
+
+	var fe *FieldError
+	if !errors.As(err, &fe) {
+		t.Fatalf("expected *FieldError, got %v", err)
+	}
+	if fe.Path != "age" || fe.Raw != "old" || fe.Target != reflect.TypeOf(0) {
+		t.Errorf("unexpected FieldError: %+v", fe)
+	}
+	if !errors.Is(err, ErrCoercion) {
+		t.Error("expected errors.Is(err, ErrCoercion)")
+	}
+	if !strings.Contains(err.Error(), "age: cannot convert string to int") {
+		t.Errorf("unexpected message: %v", err)
+	}
+}
+
+func TestParseErrorRootFailure(t *testing.T) {
+	_, err := Parse[int](`{"a": 1}`)
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected *ParseError, got %v", err)
+	}
+	if len(perr.Errors) != 1 || perr.Errors[0].Path != "" {
+		t.Errorf("expected one root error, got %+v", perr.Errors)
+	}
+}
+
+func TestErrNoJSON(t *testing.T) {
+	_, err := Parse[testEmployee]("no structured data here")
+	if !errors.Is(err, ErrNoJSON) {
+		t.Errorf("expected ErrNoJSON, got %v", err)
+	}
+}
+
+func TestErrAmbiguous(t *testing.T) {
+	_, err := Parse[testSentiment]("positive or negative")
+	if !errors.Is(err, ErrAmbiguous) {
+		t.Errorf("expected ErrAmbiguous from prose, got %v", err)
+	}
+
+	_, err = Parse[testShape](`{"radius": 1, "side": 2}`)
+	if !errors.Is(err, ErrAmbiguous) {
+		t.Errorf("expected ErrAmbiguous from union, got %v", err)
+	}
+	if !errors.Is(err, ErrCoercion) {
+		t.Errorf("expected union failure to be a coercion error, got %v", err)
+	}
+}
+
+func TestParsePrefersCandidateWithoutErrors(t *testing.T) {
+	input := "Draft: {\"name\": \"Ann\", \"age\": \"thirty\"}\n\nFinal: {\"name\": \"Ann\", \"age\": \"30 years\"}"
+
+	emp, score, err := ParseWithScore[testEmployee](input)
+	if err != nil {
+		t.Fatalf("expected clean candidate to win, got %v", err)
+	}
+	if emp.Age != 30 {
+		t.Errorf("Age = %d, want 30", emp.Age)
+	}
+	if score.Total() == 0 {
+		t.Error("expected coercion penalties from the winning candidate")
+	}
+}
+
+func TestCoerceReturnsPartialResult(t *testing.T) {
+	c := NewTypeCoercer()
+	raw := map[string]interface{}{"name": "Ann", "age": []interface{}{1}}
+
+	result, _, err := c.Coerce(raw, reflect.TypeOf(testEmployee{}))
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected *ParseError, got %v", err)
+	}
+	if result.(testEmployee).Name != "Ann" {
+		t.Errorf("expected partial result, got %+v", result)
+	}
+
+	// Errors don't leak into the next call
+	_, _, err = c.Coerce(map[string]interface{}{"name": "Bob"}, reflect.TypeOf(testEmployee{}))
+	if err != nil {
+		t.Errorf("unexpected error on reuse: %v", err)
+	}
+}
+
+func TestUnionLosingVariantErrorsDiscarded(t *testing.T) {
+	// testCalculatorTool has no "query" field, testSearchTool coerces
+	// "query" cleanly; neither error set should leak into the result.
+	call, err := Parse[testToolCall](`{"tool": {"query": "weather"}}`)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if _, ok := call.Tool.(testSearchTool); !ok {
+		t.Errorf("Tool = %#v, want testSearchTool", call.Tool)
+	}
+}
+
+func TestParseErrorMessage(t *testing.T) {
+	err := &ParseError{Errors: []FieldError{
+		{Path: "a", Cause: errors.New("bad a")},
+		{Path: "b[1]", Cause: errors.New("bad b")},
+	}}
+	want := "failed to parse: 2 errors: a: bad a; b[1]: bad b"
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
@@ -0,0 +1,352 @@
+package sap
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+)
+
+// ExampleOption configures Example.
+type ExampleOption func(*exampleBuilder)
+
+// ExampleItems sets how many elements slices and maps get; the default is
+// 1. A len= option on the field takes precedence where they conflict.
+func ExampleItems(n int) ExampleOption {
+	return func(b *exampleBuilder) { b.items = n }
+}
+
+// ExampleDepth sets how many levels of structs are filled in; the default
+// is 3. Pointers in the deepest level are null and its slices and maps are
+// empty, which also ends recursive types.
+func ExampleDepth(n int) ExampleOption {
+	return func(b *exampleBuilder) { b.depth = n }
+}
+
+// exampleStrings holds placeholders for string fields by a word in their
+// name.
+var exampleStrings = map[string]string{
+	"name":        "Jane Doe",
+	"title":       "Quarterly report",
+	"email":       "jane@example.com",
+	"phone":       "+1 555 0100",
+	"url":         "https://example.com",
+	"website":     "https://example.com",
+	"id":          "abc123",
+	"city":        "Springfield",
+	"country":     "United States",
+	"address":     "123 Main St",
+	"description": "A short description",
+	"summary":     "A short summary",
+	"comment":     "Looks good",
+	"note":        "Follow up next week",
+	"notes":       "Follow up next week",
+	"currency":    "USD",
+	"language":    "en",
+	"status":      "active",
+}
+
+// exampleFormats holds placeholders for format= options.
+var exampleFormats = map[string]string{
+	"email":     "jane@example.com",
+	"uuid":      "123e4567-e89b-12d3-a456-426614174000",
+	"url":       "https://example.com",
+	"date":      "2024-01-15",
+	"date-time": "2024-01-15T09:30:00Z",
+}
+
+// Example returns a plausible example of the JSON Parse[T] expects, to
+// paste into few-shot prompts:
+//
+//	example, err := sap.Example[Order](sap.ExampleItems(2))
+//	prompt := "Reply with JSON like this:\n" + example
+//
+// Fields appear in declaration order under the names Parse matches.
+// Values come from `example` tags where present, then `default` tags,
+// coerced as Parse coerces defaults so they appear as Parse would produce
+// them: default=a,b on a []string is a list and an enum value its name.
+// Otherwise enums take their first value, unions their first variant, and
+// other values a placeholder for their kind, format and field name that
+// satisfies the field's constraints. Pointers are filled in rather than
+// null.
+//
+// The example is parsed with Parse before it is returned. It returns an
+// error if that fails, as it can when a pattern= constraint or a Validate
+// method rejects a placeholder; give the field an `example` tag then. A
+// field of a type Parse can't fill, like a chan, or with a malformed
+// `gsap` tag is an error too, even if it has an `example` tag.
+func Example[T any](opts ...ExampleOption) (string, error) {
+	return exampleFor(reflect.TypeOf((*T)(nil)).Elem(), opts...)
+}
+
+// exampleFor returns the example for t.
+func exampleFor(t reflect.Type, opts ...ExampleOption) (string, error) {
+	b := &exampleBuilder{items: 1, depth: 3}
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	v, err := b.value(t, fieldOptions{}, "", "", 0)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", describeError("generate example", "", err)
+	}
+
+	if _, err := DefaultParser.Parse(string(data), t); err != nil {
+		return "", fmt.Errorf("generated example for %v does not parse; add example tags: %w", t, err)
+	}
+	return string(data), nil
+}
+
+// exampleBuilder generates example values.
+type exampleBuilder struct {
+	items int // elements in slices and maps
+	depth int // levels of nested structs to fill in
+}
+
+// exampleObject is a JSON object that keeps its members in order.
+type exampleObject []exampleMember
+
+type exampleMember struct {
+	key   string
+	value interface{}
+}
+
+// MarshalJSON implements json.Marshaler.
+func (o exampleObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, m := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(m.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(m.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// value returns an example value of type t for the field name with the
+// given options, depth structs deep.
+func (b *exampleBuilder) value(t reflect.Type, opts fieldOptions, name, path string, depth int) (interface{}, error) {
+	shape, err := shapeOf(t, opts)
+	if err != nil {
+		return nil, describeError("generate example", path, err)
+	}
+	switch {
+	case opts.hasExample:
+		v, err := exampleTagValue(t, opts)
+		if err != nil {
+			return nil, describeError("generate example", path, fmt.Errorf("invalid example %q: %w", opts.example, err))
+		}
+		return v, nil
+	case opts.hasDefault:
+		v, err := tagValueJSON(opts.defaultValue, t, opts)
+		if err != nil {
+			return nil, describeError("generate example", path, fmt.Errorf("invalid default %q: %w", opts.defaultValue, err))
+		}
+		return v, nil
+	}
+
+	switch shape.kind {
+	case shapeTime:
+		return exampleFormats["date-time"], nil
+	case shapeText, shapeRaw:
+		// The zero value is the most plausible input the type is sure to
+		// accept
+		zero := reflect.New(t).Interface()
+		if m, ok := zero.(encoding.TextMarshaler); ok {
+			text, err := m.MarshalText()
+			if err == nil {
+				return string(text), nil
+			}
+		}
+		if m, ok := zero.(json.Marshaler); ok {
+			data, err := m.MarshalJSON()
+			if err == nil {
+				return json.RawMessage(data), nil
+			}
+		}
+		return nil, nil
+	case shapePointer:
+		if depth >= b.depth {
+			return nil, nil
+		}
+		return b.value(t.Elem(), opts, name, path, depth)
+	case shapeAny:
+		return exampleString(name, opts), nil
+	case shapeUnion:
+		def := shape.union
+		variant := def.variants[0]
+		for variant.Kind() == reflect.Ptr {
+			variant = variant.Elem()
+		}
+		v, err := b.value(variant, fieldOptions{}, name, path, depth)
+		if err != nil {
+			return nil, err
+		}
+		if obj, ok := v.(exampleObject); ok && def.discriminator != "" {
+			tag := exampleMember{key: def.discriminator, value: def.tagNames()[0]}
+			out := exampleObject{tag}
+			for _, m := range obj {
+				if m.key != def.discriminator {
+					out = append(out, m)
+				}
+			}
+			return out, nil
+		}
+		return v, nil
+	case shapeEnum:
+		return shape.enum.names[0], nil
+	case shapeString:
+		return exampleString(name, opts), nil
+	case shapeInt, shapeUint:
+		n := math.Ceil(exampleNumber(1, opts))
+		if opts.max != nil && n > *opts.max {
+			n = math.Floor(*opts.max)
+		}
+		return int64(n), nil
+	case shapeFloat:
+		return exampleNumber(1.5, opts), nil
+	case shapeBool:
+		return true, nil
+	case shapeList:
+		n := b.count(opts)
+		if t.Kind() == reflect.Array {
+			n = t.Len()
+		} else if depth >= b.depth {
+			n = 0
+		}
+		items := make([]interface{}, n)
+		elemOpts := opts
+		elemOpts.minLen, elemOpts.maxLen = nil, nil
+		for i := range items {
+			v, err := b.value(t.Elem(), elemOpts, name, path+"[]", depth)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = v
+		}
+		return items, nil
+	case shapeMap:
+		n := b.count(opts)
+		if depth >= b.depth {
+			n = 0
+		}
+		obj := exampleObject{}
+		for i := 0; i < n; i++ {
+			v, err := b.value(t.Elem(), fieldOptions{}, name, path+"{}", depth)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, exampleMember{key: fmt.Sprintf("key%d", i+1), value: v})
+		}
+		return obj, nil
+	default: // shapeStruct
+		obj := exampleObject{}
+		plan := structPlanFor(t)
+		for i := range plan.fields {
+			fp := &plan.fields[i]
+			if fp.opts.skip {
+				continue
+			}
+			v, err := b.value(fp.field.Type, fp.opts, fp.pathName, joinSchemaPath(path, fp.pathName), depth+1)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, exampleMember{key: fp.pathName, value: v})
+		}
+		return obj, nil
+	}
+}
+
+// count returns the number of elements for a slice or map field.
+func (b *exampleBuilder) count(opts fieldOptions) int {
+	n := b.items
+	if opts.maxLen != nil && n > *opts.maxLen {
+		n = *opts.maxLen
+	}
+	if opts.minLen != nil && n < *opts.minLen {
+		n = *opts.minLen
+	}
+	return n
+}
+
+// exampleTagValue returns the example tag of a field of type t as the
+// value Parse would produce from it, in JSON: coerced like a default, so
+// "a,b" is a list for a []string and a date a full date-time for a
+// time.Time. For types not read from strings, the tag may also be JSON.
+func exampleTagValue(t reflect.Type, opts fieldOptions) (interface{}, error) {
+	var raw interface{} = opts.example
+	base := t
+	for base.Kind() == reflect.Ptr {
+		base = base.Elem()
+	}
+	fromString := base.Kind() == reflect.String || base == timeType || reflect.PointerTo(base).Implements(textUnmarshalerType)
+	if !fromString && json.Valid([]byte(opts.example)) {
+		if err := json.Unmarshal([]byte(opts.example), &raw); err != nil {
+			return nil, err
+		}
+	}
+	return tagValueJSON(raw, t, opts)
+}
+
+// exampleString returns a placeholder string for the field name that
+// satisfies opts.
+func exampleString(name string, opts fieldOptions) string {
+	s, ok := exampleFormats[opts.format]
+	if !ok {
+		s = examplePlaceholder(name)
+	}
+	if opts.maxLen != nil && utf8.RuneCountInString(s) > *opts.maxLen {
+		s = strings.TrimSpace(string([]rune(s)[:*opts.maxLen]))
+	}
+	if n := utf8.RuneCountInString(s); opts.minLen != nil && n < *opts.minLen {
+		s += strings.Repeat("x", *opts.minLen-n)
+	}
+	return s
+}
+
+// examplePlaceholder returns a placeholder for a string field, chosen by
+// the last word of its name that has one, e.g. "jane@example.com" for
+// "contact_email".
+func examplePlaceholder(name string) string {
+	words := splitWords(toSnakeCase(name))
+	for i := len(words) - 1; i >= 0; i-- {
+		if s, ok := exampleStrings[words[i]]; ok {
+			return s
+		}
+	}
+	if name == "" {
+		return "example"
+	}
+	return "example " + strings.Join(words, " ")
+}
+
+// exampleNumber returns n moved into the field's min/max range.
+func exampleNumber(n float64, opts fieldOptions) float64 {
+	if opts.max != nil && n > *opts.max {
+		n = *opts.max
+	}
+	if opts.min != nil && n < *opts.min {
+		n = *opts.min
+	}
+	return n
+}
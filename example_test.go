@@ -0,0 +1,211 @@
+package sap
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testExampleOrder struct {
+	ID       string            `json:"id" example:"ORD-1042"`
+	Status   string            `json:"status" gsap:"enum=pending|shipped"`
+	Priority testPriority      `json:"priority"`
+	Customer testExampleUser   `json:"customer"`
+	Items    []testExampleItem `json:"items" gsap:"len=1..5"`
+	Total    float64           `json:"total" gsap:"min=10"`
+	Rating   int               `json:"rating" gsap:"min=3,max=5"`
+	Code     string            `json:"code" gsap:"len=6..8"`
+	Due      string            `json:"due" gsap:"format=date"`
+	Placed   time.Time         `json:"placed"`
+	Express  bool              `json:"express" gsap:"default=false"`
+	Labels   map[string]int    `json:"labels"`
+	Note     *string           `json:"note"`
+	Internal string            `json:"-"`
+}
+
+type testExampleUser struct {
+	FullName     string `json:"full_name"`
+	ContactEmail string `json:"contact_email"`
+}
+
+type testExampleItem struct {
+	SKU      string   `json:"sku" example:"SKU-7"`
+	Quantity int      `json:"quantity" example:"2"`
+	Tags     []string `json:"tags" example:"[\"new\", \"sale\"]"`
+}
+
+func TestExample(t *testing.T) {
+	got, err := Example[testExampleOrder]()
+	if err != nil {
+		t.Fatalf("Example failed: %v", err)
+	}
+	want := `{
+  "id": "ORD-1042",
+  "status": "pending",
+  "priority": "Low",
+  "customer": {
+    "full_name": "Jane Doe",
+    "contact_email": "jane@example.com"
+  },
+  "items": [
+    {
+      "sku": "SKU-7",
+      "quantity": 2,
+      "tags": [
+        "new",
+        "sale"
+      ]
+    }
+  ],
+  "total": 10,
+  "rating": 3,
+  "code": "example",
+  "due": "2024-01-15",
+  "placed": "2024-01-15T09:30:00Z",
+  "express": false,
+  "labels": {
+    "key1": 1
+  },
+  "note": "Follow up next week"
+}`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	order, err := Parse[testExampleOrder](got)
+	if err != nil {
+		t.Fatalf("Parse rejects example: %v", err)
+	}
+	if order.Customer.ContactEmail != "jane@example.com" || order.Items[0].Quantity != 2 {
+		t.Errorf("unexpected parse of example: %+v", order)
+	}
+}
+
+func TestExampleOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		example func(...ExampleOption) (string, error)
+		opts    []ExampleOption
+		want    string
+		times   int // occurrences of want, if not 1
+	}{
+		{
+			name:    "items",
+			example: Example[[]string],
+			opts:    []ExampleOption{ExampleItems(3)},
+			want:    `["example","example","example"]`,
+		},
+		{
+			name:    "items bounded by len",
+			example: Example[testExampleOrder],
+			opts:    []ExampleOption{ExampleItems(9)},
+			want:    `{"sku":"SKU-7"`,
+			times:   5,
+		},
+		{
+			name:    "no items",
+			example: Example[testExampleOrder],
+			opts:    []ExampleOption{ExampleItems(0)},
+			want:    `"labels":{}`,
+		},
+		{
+			name:    "recursive",
+			example: Example[testSchemaNode],
+			want:    `{"value":1,"children":[{"value":1,"children":[{"value":1,"children":[],"next":null}],"next":{"head":null}}],"next":{"head":{"value":1,"children":[],"next":null}}}`,
+		},
+		{
+			name:    "shallow",
+			example: Example[testSchemaNode],
+			opts:    []ExampleOption{ExampleDepth(1)},
+			want:    `{"value":1,"children":[],"next":null}`,
+		},
+		{
+			name:    "tagged union",
+			example: Example[testToolCall],
+			want:    `{"tool":{"type":"calculator","expression":"example expression"}}`,
+		},
+		{
+			name:    "untagged union",
+			example: Example[testDrawing],
+			want:    `"main":{"radius":1.5}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.example(tt.opts...)
+			if err != nil {
+				t.Fatalf("Example failed: %v", err)
+			}
+			var compact bytes.Buffer
+			if err := json.Compact(&compact, []byte(got)); err != nil {
+				t.Fatalf("invalid JSON %s: %v", got, err)
+			}
+			times := tt.times
+			if times == 0 {
+				times = 1
+			}
+			if n := strings.Count(compact.String(), tt.want); n != times {
+				t.Errorf("got %s, want %d of %s", compact.String(), times, tt.want)
+			}
+		})
+	}
+}
+
+type testExampleDefaults struct {
+	Labels   []string     `json:"labels" gsap:"default='a,b'"`
+	Since    time.Time    `json:"since" gsap:"default=2024-01-02"`
+	Priority testPriority `json:"priority" gsap:"default=high"`
+	Score    *int         `json:"score" example:"7"`
+	Units    []string     `json:"units" example:"kg, lb"`
+}
+
+func TestExampleTagsCoerced(t *testing.T) {
+	got, err := Example[testExampleDefaults]()
+	if err != nil {
+		t.Fatalf("Example failed: %v", err)
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, []byte(got)); err != nil {
+		t.Fatalf("invalid JSON %s: %v", got, err)
+	}
+	want := `{"labels":["a","b"],"since":"2024-01-02T00:00:00Z","priority":"High","score":7,"units":["kg","lb"]}`
+	if compact.String() != want {
+		t.Errorf("got  %s\nwant %s", compact.String(), want)
+	}
+}
+
+func TestExampleErrors(t *testing.T) {
+	type badChan struct {
+		Events chan int `json:"events"`
+	}
+	type badTag struct {
+		Age int `json:"age" gsap:"min=old"`
+	}
+	type badPattern struct {
+		Code string `json:"code" gsap:"pattern=^[A-Z]{3}$"`
+	}
+	type badExample struct {
+		Count int `json:"count" example:"many"`
+	}
+	type goodPattern struct {
+		Code string `json:"code" gsap:"pattern=^[A-Z]{3}$" example:"ABC"`
+	}
+
+	if _, err := Example[badChan](); err == nil || !strings.Contains(err.Error(), "events") {
+		t.Errorf("expected error for chan field, got %v", err)
+	}
+	if _, err := Example[badTag](); err == nil || !strings.Contains(err.Error(), "age") {
+		t.Errorf("expected error for invalid tag, got %v", err)
+	}
+	if _, err := Example[badPattern](); err == nil || !strings.Contains(err.Error(), "example tags") {
+		t.Errorf("expected error for unmatched pattern, got %v", err)
+	}
+	if _, err := Example[badExample](); err == nil || !strings.Contains(err.Error(), `invalid example "many"`) {
+		t.Errorf("expected error for invalid example tag, got %v", err)
+	}
+	if _, err := Example[goodPattern](); err != nil {
+		t.Errorf("example tag should satisfy pattern: %v", err)
+	}
+}
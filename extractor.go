@@ -2,7 +2,6 @@ package sap
 
 import (
 	"encoding/json"
-	"fmt"
 	"regexp"
 	"strings"
 )
@@ -43,7 +42,7 @@ func (e *Extractor) ExtractJSON(input string) ([]JSONCandidate, error) {
 	candidates = append(candidates, naiveJSONs...)
 
 	if len(candidates) == 0 {
-		return nil, fmt.Errorf("no JSON found in input")
+		return nil, ErrNoJSON
 	}
 
 	return candidates, nil
@@ -138,6 +137,15 @@ func (e *Extractor) findJSONBlocks(input string, openChar, closeChar rune) []JSO
 					JSON:  json,
 					Index: i,
 				})
+			} else if e.parser.allowIncomplete {
+				// The input ends inside this block, e.g. a stream cut
+				// off mid-object. Every later opening is nested inside
+				// it, so it is the only candidate left.
+				candidates = append(candidates, JSONCandidate{
+					JSON:  string(runes[i:]),
+					Index: i,
+				})
+				break
 			}
 		}
 	}
@@ -1,6 +1,8 @@
 package sap
 
 import (
+	"encoding/json"
+	"regexp"
 	"strings"
 	"unicode"
 )
@@ -8,8 +10,9 @@ import (
 // FixJSON attempts to fix malformed JSON
 func FixJSON(input string) (string, error) {
 	parser := &fixingParserState{
-		input: input,
-		runes: []rune(input),
+		input:    input,
+		runes:    []rune(input),
+		keyStart: -1,
 	}
 	return parser.parse()
 }
@@ -26,6 +29,7 @@ type fixingParserState struct {
 	stringEscaped      bool
 	lastNonWhitespace  rune
 	bracketStack       []rune // Stack of open brackets/braces
+	keyStart           int    // Offset in result of the latest object key, or -1
 }
 
 func (p *fixingParserState) parse() (string, error) {
@@ -75,6 +79,7 @@ func (p *fixingParserState) handleNonStringChar(ch rune) {
 	switch ch {
 	case '"':
 		// Start of quoted string
+		p.markKeyStart()
 		p.result.WriteRune('"')
 		p.inString = true
 		p.stringQuoteChar = '"'
@@ -82,6 +87,7 @@ func (p *fixingParserState) handleNonStringChar(ch rune) {
 
 	case '\'':
 		// Single quote - convert to double quote
+		p.markKeyStart()
 		p.result.WriteRune('"')
 		p.inString = true
 		p.stringQuoteChar = '\''
@@ -89,6 +95,7 @@ func (p *fixingParserState) handleNonStringChar(ch rune) {
 
 	case '`':
 		// Backtick - convert to double quote
+		p.markKeyStart()
 		p.result.WriteRune('"')
 		p.inString = true
 		p.stringQuoteChar = '`'
@@ -102,13 +109,15 @@ func (p *fixingParserState) handleNonStringChar(ch rune) {
 
 	case ':':
 		// Quote any unquoted key before the colon
-		p.quoteUnquotedKey()
+		if p.lastNonWhitespace != '"' {
+			p.quoteUnquotedKey()
+		}
 		p.result.WriteRune(ch)
 		p.lastNonWhitespace = ch
 
 	case ',':
 		// Quote any unquoted value before the comma
-		if p.lastNonWhitespace != '{' && p.lastNonWhitespace != '[' && p.lastNonWhitespace != ',' && p.lastNonWhitespace != '"' {
+		if p.afterUnquotedValue() {
 			p.quoteUnquotedValue()
 		}
 		p.result.WriteRune(ch)
@@ -182,6 +191,7 @@ func (p *fixingParserState) quoteUnquotedKey() {
 			// Quote the unquoted key
 			p.result.Reset()
 			p.result.WriteString(before)
+			p.keyStart = p.result.Len() + 1
 			p.result.WriteString(" \"")
 			p.result.WriteString(unquoted)
 			p.result.WriteString("\"")
@@ -189,6 +199,17 @@ func (p *fixingParserState) quoteUnquotedKey() {
 	}
 }
 
+// afterUnquotedValue reports whether the last thing written could be an
+// unquoted value, rather than a string, a complete array or object, or
+// punctuation.
+func (p *fixingParserState) afterUnquotedValue() bool {
+	switch p.lastNonWhitespace {
+	case '{', '[', ',', ':', '"', '}', ']':
+		return false
+	}
+	return true
+}
+
 func (p *fixingParserState) quoteUnquotedValue() {
 	// Find and quote any unquoted value
 	str := p.result.String()
@@ -221,16 +242,23 @@ func (p *fixingParserState) quoteUnquotedValue() {
 }
 
 func (p *fixingParserState) closeUnclosedStructures() {
+	// Terminate a string cut off mid-value
+	if p.inString {
+		p.dropPartialEscape()
+		p.result.WriteRune('"')
+		p.inString = false
+		p.lastNonWhitespace = '"'
+	}
+	if p.afterUnquotedValue() {
+		p.completeTrailingWord()
+	}
+	p.dropDanglingKey()
+
 	// Close any remaining open brackets in reverse order
 	for len(p.bracketStack) > 0 {
 		lastOpen := p.bracketStack[len(p.bracketStack)-1]
 		p.bracketStack = p.bracketStack[:len(p.bracketStack)-1]
 
-		// Quote any pending unquoted value
-		if lastOpen == '}' {
-			p.quoteUnquotedValue()
-		}
-
 		if lastOpen == '{' {
 			p.removeTrailingComma()
 			p.result.WriteRune('}')
@@ -241,6 +269,108 @@ func (p *fixingParserState) closeUnclosedStructures() {
 	}
 }
 
+// dropPartialEscape removes an escape sequence cut off at the end of a
+// string, as in `"tab\` or `"caf\u00`, which would otherwise escape the
+// closing quote or leave an invalid \u escape.
+func (p *fixingParserState) dropPartialEscape() {
+	str := p.result.String()
+	cut := len(str)
+	if p.stringEscaped {
+		cut--
+		p.stringEscaped = false
+	} else if m := rePartialUnicodeEscape.FindStringIndex(str); m != nil {
+		// Only if the backslash isn't itself escaped
+		backslashes := 0
+		for i := m[0]; i >= 0 && str[i] == '\\'; i-- {
+			backslashes++
+		}
+		if backslashes%2 == 1 {
+			cut = m[0]
+		}
+	}
+	p.result.Reset()
+	p.result.WriteString(str[:cut])
+}
+
+// rePartialUnicodeEscape matches a \u escape missing some of its digits.
+var rePartialUnicodeEscape = regexp.MustCompile(`\\u[0-9a-fA-F]{0,3}$`)
+
+// completeTrailingWord finishes an unquoted word cut off at the end of the
+// input. A prefix of true, false or null is completed, as in
+// `{"paid": tr`, and a number loses a dangling sign, point or exponent, as
+// in `{"total": 12.`. Any other value is quoted, and an unquoted key with
+// no value yet is dropped.
+func (p *fixingParserState) completeTrailingWord() {
+	str := strings.TrimRight(p.result.String(), " \t\n\r")
+	start := strings.LastIndexAny(str, "{[,:") + 1
+	word := strings.TrimSpace(str[start:])
+	if word == "" || start == 0 {
+		return
+	}
+
+	inObject := len(p.bracketStack) > 0 && p.bracketStack[len(p.bracketStack)-1] == '{'
+	if str[start-1] == '{' || str[start-1] == ',' && inObject {
+		p.result.Reset()
+		p.result.WriteString(str[:start])
+		p.removeTrailingComma()
+		return
+	}
+
+	completed := ""
+	switch {
+	case strings.HasPrefix("true", word) || strings.HasPrefix("false", word) || strings.HasPrefix("null", word):
+		for _, literal := range []string{"true", "false", "null"} {
+			if strings.HasPrefix(literal, word) {
+				completed = literal
+			}
+		}
+	case rePartialNumber.MatchString(word):
+		completed = strings.TrimRight(word, ".eE+-")
+	default:
+		p.quoteUnquotedValue()
+		return
+	}
+	p.result.Reset()
+	p.result.WriteString(str[:start])
+	p.result.WriteString(completed)
+	if completed == "" {
+		p.removeTrailingComma()
+	}
+}
+
+// rePartialNumber matches a number that may be cut off part way through.
+var rePartialNumber = regexp.MustCompile(`^-?[0-9]*(\.[0-9]*)?([eE][+-]?[0-9]*)?$`)
+
+// markKeyStart remembers where a string starting an object key begins.
+func (p *fixingParserState) markKeyStart() {
+	if len(p.bracketStack) > 0 && p.bracketStack[len(p.bracketStack)-1] == '{' &&
+		(p.lastNonWhitespace == '{' || p.lastNonWhitespace == ',') {
+		p.keyStart = p.result.Len()
+	}
+}
+
+// dropDanglingKey removes an object key whose value was cut off, as in
+// `{"name": "Alice", "ema` or `{"name": "Alice", "email":`, so the
+// truncated object still closes into valid JSON.
+func (p *fixingParserState) dropDanglingKey() {
+	if p.keyStart < 0 || len(p.bracketStack) == 0 || p.bracketStack[len(p.bracketStack)-1] != '{' {
+		return
+	}
+	str := p.result.String()
+	rest := strings.TrimSpace(str[p.keyStart:])
+	rest = strings.TrimSpace(strings.TrimSuffix(rest, ":"))
+
+	// Only drop it if nothing but the key follows
+	var key string
+	if json.Unmarshal([]byte(rest), &key) != nil {
+		return
+	}
+	p.result.Reset()
+	p.result.WriteString(str[:p.keyStart])
+	p.keyStart = -1
+	p.removeTrailingComma()
+}
+
 func (p *fixingParserState) removeTrailingComma() {
 	// Remove trailing comma if present
 	str := p.result.String()
@@ -275,7 +405,7 @@ func (p *fixingParserState) handleCloseBracket(ch rune) {
 
 	if ch == expectedClose {
 		// Quote any pending unquoted value
-		if expectedClose == '}' {
+		if expectedClose == '}' && p.afterUnquotedValue() {
 			p.quoteUnquotedValue()
 		}
 
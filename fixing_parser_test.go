@@ -660,6 +660,18 @@ func TestFixJSON_StructuralCharsInStrings(t *testing.T) {
 			checkKey: "nl",
 			wantVal:  "a\nb",
 		},
+		{
+			name:     "colon inside last string value of unquoted key",
+			input:    `{url: 'https://example.com'}`,
+			checkKey: "url",
+			wantVal:  "https://example.com",
+		},
+		{
+			name:     "brace inside quoted key before unquoted key",
+			input:    `{"{x}": "v", y: 1}`,
+			checkKey: "{x}",
+			wantVal:  "v",
+		},
 	}
 
 	for _, tt := range tests {
@@ -887,6 +899,26 @@ func TestFixJSON_TruncatedAfterCompleteValues(t *testing.T) {
 			input:    `{"user": {"name": "Bob"`,
 			wantKeys: []string{"user"},
 		},
+		{
+			name:     "truncated inside a string value",
+			input:    `{"name": "Alice", "email": "alice@exa`,
+			wantKeys: []string{"name", "email"},
+		},
+		{
+			name:     "truncated inside a key",
+			input:    `{"name": "Alice", "ema`,
+			wantKeys: []string{"name"},
+		},
+		{
+			name:     "truncated after a colon",
+			input:    `{"name": "Alice", "email":`,
+			wantKeys: []string{"name"},
+		},
+		{
+			name:     "truncated after a nested object",
+			input:    `{"user": {"age": 30}, "na`,
+			wantKeys: []string{"user"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -907,6 +939,46 @@ func TestFixJSON_TruncatedAfterCompleteValues(t *testing.T) {
 	}
 }
 
+func TestFixJSON_TruncatedMidValue(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`{"a": 1, "b": nu`, `{"a":1,"b":null}`},
+		{`{"a": 1, "b": f`, `{"a":1,"b":false}`},
+		{`[1, 2, tr`, `[1,2,true]`},
+		{`{"a": 1, "b": 12.`, `{"a":1,"b":12}`},
+		{`{"a": 1, "b": 1e-`, `{"a":1,"b":1}`},
+		{`{"a": 1, "b": -`, `{"a":1}`},
+		{`[1, -`, `[1]`},
+		{`{"a": 1, na`, `{"a":1}`},
+		{`{"a": 1, name:`, `{"a":1}`},
+		{`{"a": "tab\`, `{"a":"tab"}`},
+		{`{"a": "caf\u00`, `{"a":"caf"}`},
+		{`{"a": "x\\u00`, `{"a":"x\\u00"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := FixJSON(tt.input)
+			if err != nil {
+				t.Fatalf("FixJSON returned error: %v", err)
+			}
+			mustBeValidJSON(t, got)
+
+			var compact strings.Builder
+			for _, r := range got {
+				if r != ' ' {
+					compact.WriteRune(r)
+				}
+			}
+			if compact.String() != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Value preservation: verify specific values survive the fixing process
 // ---------------------------------------------------------------------------
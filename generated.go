@@ -0,0 +1,261 @@
+package sap
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+)
+
+// StructState is a struct being coerced by code generated with gsapgen.
+// The generated code passes it to Field, or to the variant for the field's
+// kind such as FieldString, once for each field.
+type StructState struct {
+	c     *TypeCoercer
+	plan  *structPlan
+	keys  keyMatcher
+	score *Score
+}
+
+// generatedStruct builds a struct from st with generated code.
+type generatedStruct func(st *StructState) reflect.Value
+
+var (
+	generatedMu       sync.RWMutex
+	generatedRegistry = make(map[reflect.Type]generatedStruct)
+)
+
+// RegisterGenerated installs fn, generated by gsapgen, as the coercion for
+// struct type T. Everything around the fields, such as custom coercers,
+// null strings and Validate methods, is handled as for any other struct.
+//
+// fields lists the Go selectors of T's exported fields in the order fn
+// sets them. If T has changed since fn was generated, the lists differ and
+// RegisterGenerated panics, so stale code fails at startup rather than
+// filling the wrong fields.
+//
+// It is called from the init function of generated code.
+func RegisterGenerated[T any](fn func(st *StructState) T, fields ...string) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("sap: cannot register generated coercer for non-struct type %v", t))
+	}
+	plan := structPlanFor(t)
+	if len(fields) != len(plan.fields) {
+		panic(fmt.Sprintf("sap: generated coercer for %v is out of date: it has %d fields, the type has %d; re-run gsapgen", t, len(fields), len(plan.fields)))
+	}
+	for i, name := range fields {
+		if name != plan.fields[i].goPath {
+			panic(fmt.Sprintf("sap: generated coercer for %v is out of date: field %d is %s, want %s; re-run gsapgen", t, i, name, plan.fields[i].goPath))
+		}
+	}
+
+	generatedMu.Lock()
+	defer generatedMu.Unlock()
+	generatedRegistry[t] = func(st *StructState) reflect.Value {
+		v := fn(st)
+		return reflect.ValueOf(&v).Elem()
+	}
+}
+
+// lookupGenerated returns the generated coercer for struct type t, or nil.
+func lookupGenerated(t reflect.Type) generatedStruct {
+	generatedMu.RLock()
+	defer generatedMu.RUnlock()
+	return generatedRegistry[t]
+}
+
+// Field coerces field i of the struct being built into dst, which points
+// to that field. The field is matched to an input key and coerced with the
+// same rules as any other struct field, including its tag options; if it
+// is missing or fails, dst is left unchanged and the failure is reported
+// in the parse's errors.
+func Field[F any](st *StructState, i int, dst *F) {
+	elem, ok := st.c.coerceField(&st.keys, i, st.score)
+	if !ok || elem == nil {
+		return
+	}
+	// Coercion returns a value of the field's own type
+	*dst = elem.(F)
+}
+
+// direct returns the input value for field i if generated code may
+// convert it itself, and nil if the field must go through Field: it is
+// missing or null, or its type has a custom coercer, an enum, or tag
+// options that change how it is coerced.
+func (st *StructState) direct(i int) (raw interface{}, fuzzy bool) {
+	fp := &st.plan.fields[i]
+	if !fp.direct {
+		return nil, false
+	}
+	key, fuzzy := st.keys.find(i)
+	if key == "" {
+		return nil, false
+	}
+	raw = st.keys.mapVal[key]
+	if raw == nil || st.c.lookupCoercer(fp.field.Type) != nil || lookupEnum(fp.field.Type) != nil {
+		return nil, false
+	}
+	return raw, fuzzy
+}
+
+// set stores v, converted from the input by generated code, in field i,
+// checking its constraints and recording its presence as Field would.
+func set[F any](st *StructState, i int, fuzzy bool, dst *F, v F) {
+	fp := &st.plan.fields[i]
+	c := st.c
+	if fuzzy {
+		st.score.AddFlag(FlagFuzzyFieldMatch, 1)
+	}
+	c.pushPath(fp.pathName)
+	defer c.popPath()
+	if fp.opts.hasConstraints() && !c.checkConstraints(v, fp.field.Type, fp.opts, st.score) {
+		return
+	}
+	*dst = v
+	c.markPresence(FieldPopulated)
+}
+
+// FieldString is Field for string fields. A JSON string is stored
+// directly; anything else, including null strings like "N/A", goes
+// through Field.
+func FieldString[F ~string](st *StructState, i int, dst *F) {
+	raw, fuzzy := st.direct(i)
+	if s, ok := raw.(string); ok && !isNullString(s) {
+		set(st, i, fuzzy, dst, F(s))
+		return
+	}
+	Field(st, i, dst)
+}
+
+// FieldBool is Field for bool fields. A JSON boolean is stored directly;
+// anything else goes through Field.
+func FieldBool[F ~bool](st *StructState, i int, dst *F) {
+	raw, fuzzy := st.direct(i)
+	if b, ok := raw.(bool); ok {
+		set(st, i, fuzzy, dst, F(b))
+		return
+	}
+	Field(st, i, dst)
+}
+
+// FieldInt is Field for signed integer fields. A JSON number is truncated
+// to an integer, flagged if it had a fraction; anything else goes through
+// Field.
+func FieldInt[F ~int | ~int8 | ~int16 | ~int32 | ~int64](st *StructState, i int, dst *F) {
+	raw, fuzzy := st.direct(i)
+	if f, ok := raw.(float64); ok {
+		n := int64(f)
+		if float64(n) != f {
+			st.score.AddFlag(FlagFloatToInt, 1)
+		}
+		set(st, i, fuzzy, dst, F(n))
+		return
+	}
+	Field(st, i, dst)
+}
+
+// FieldUint is Field for unsigned integer fields, converting JSON numbers
+// as FieldInt does.
+func FieldUint[F ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64](st *StructState, i int, dst *F) {
+	raw, fuzzy := st.direct(i)
+	if f, ok := raw.(float64); ok {
+		n := int64(f)
+		if float64(n) != f {
+			st.score.AddFlag(FlagFloatToInt, 1)
+		}
+		set(st, i, fuzzy, dst, F(uint64(n)))
+		return
+	}
+	Field(st, i, dst)
+}
+
+// FieldFloat is Field for floating-point fields. A JSON number is stored
+// directly; anything else goes through Field.
+func FieldFloat[F ~float32 | ~float64](st *StructState, i int, dst *F) {
+	raw, fuzzy := st.direct(i)
+	if f, ok := raw.(float64); ok {
+		set(st, i, fuzzy, dst, F(f))
+		return
+	}
+	Field(st, i, dst)
+}
+
+// FieldStruct is Field for struct fields whose type has generated code,
+// passed as fields. A JSON object is coerced by calling fields directly;
+// anything else goes through Field.
+func FieldStruct[F any](st *StructState, i int, dst *F, fields func(st *StructState) F) {
+	raw, fuzzy := st.direct(i)
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		Field(st, i, dst)
+		return
+	}
+
+	fp := &st.plan.fields[i]
+	c := st.c
+	if fuzzy {
+		st.score.AddFlag(FlagFuzzyFieldMatch, 1)
+	}
+	c.pushPath(fp.pathName)
+	defer c.popPath()
+	v := coerceGenerated(c, fp.field.Type, m, st.score, fields)
+	if fp.opts.hasConstraints() && !c.checkConstraints(v, fp.field.Type, fp.opts, st.score) {
+		return
+	}
+	*dst = v
+	c.markPresence(FieldPopulated)
+}
+
+// coerceGenerated builds a struct of type t from m with its generated
+// fields function, then checks it as coerceToStruct does.
+func coerceGenerated[T any](c *TypeCoercer, t reflect.Type, m map[string]interface{}, score *Score, fields func(st *StructState) T) T {
+	mark := len(c.errors)
+	plan := structPlanFor(t)
+	c.presence = slices.Grow(c.presence, len(plan.fields))
+	v := fields(&StructState{c: c, plan: plan, keys: plan.matchKeys(m), score: score})
+
+	if plan.hasEmbedded {
+		score.AddFlag(FlagEmbeddedStruct, 0)
+	}
+	if len(c.errors) == mark && plan.validates {
+		c.reportValidation(interface{}(&v).(Validator).Validate(), t, score)
+	}
+	return v
+}
+
+// Coerce converts raw, a value decoded from JSON, to T with the same rules
+// as Parse, adding the flags for any coercion to score. Failed fields are
+// left at their zero value and reported in a *ParseError.
+func Coerce[T any](raw interface{}, score *Score) (T, error) {
+	result, s, err := NewTypeCoercer().Coerce(raw, reflect.TypeOf((*T)(nil)).Elem())
+	if score != nil {
+		score.merge(s)
+	}
+	v, _ := result.(T)
+	return v, err
+}
+
+// CoerceGenerated is Coerce for struct types with generated code, passed
+// as fields. A JSON object is coerced by calling fields directly; anything
+// else goes through Coerce.
+//
+// Coercers generated by gsapgen are thin wrappers around it.
+func CoerceGenerated[T any](raw interface{}, score *Score, fields func(st *StructState) T) (T, error) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	c := NewTypeCoercer()
+	m, ok := raw.(map[string]interface{})
+	if !ok || c.lookupCoercer(t) != nil || isUnmarshaler(t) {
+		return Coerce[T](raw, score)
+	}
+
+	s := &Score{}
+	v := coerceGenerated(c, t, m, s, fields)
+	if score != nil {
+		score.merge(s)
+	}
+	if len(c.errors) > 0 {
+		return v, &ParseError{Errors: c.errors}
+	}
+	return v, nil
+}
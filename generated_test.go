@@ -0,0 +1,159 @@
+package sap
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type generatedTestItem struct {
+	Name  string `json:"name" gsap:"required"`
+	Count int    `json:"count"`
+	note  string
+}
+
+// generatedTestKinds has a field of each kind with a typed setter.
+type generatedTestKinds struct {
+	Name      string            `json:"name"`
+	Count     int               `json:"count" gsap:"min=0"`
+	Size      uint8             `json:"size"`
+	Ratio     float32           `json:"ratio"`
+	On        bool              `json:"on"`
+	Sentiment testSentiment     `json:"sentiment"`
+	Item      generatedTestItem `json:"item"`
+}
+
+// generatedTestKindsReflected has the same fields as generatedTestKinds
+// but no generated coercer.
+type generatedTestKindsReflected generatedTestKinds
+
+func init() {
+	// What gsapgen would write for generatedTestItem and generatedTestKinds
+	RegisterGenerated(func(st *StructState) (v generatedTestItem) {
+		Field(st, 0, &v.Name)
+		Field(st, 1, &v.Count)
+		return v
+	}, "Name", "Count")
+	RegisterGenerated(coerceGeneratedTestKindsFields,
+		"Name", "Count", "Size", "Ratio", "On", "Sentiment", "Item")
+}
+
+func coerceGeneratedTestKindsFields(st *StructState) (v generatedTestKinds) {
+	FieldString(st, 0, &v.Name)
+	FieldInt(st, 1, &v.Count)
+	FieldUint(st, 2, &v.Size)
+	FieldFloat(st, 3, &v.Ratio)
+	FieldBool(st, 4, &v.On)
+	FieldString(st, 5, &v.Sentiment)
+	FieldStruct(st, 6, &v.Item, func(st *StructState) (v generatedTestItem) {
+		FieldString(st, 0, &v.Name)
+		FieldInt(st, 1, &v.Count)
+		return v
+	})
+	return v
+}
+
+func TestRegisterGenerated(t *testing.T) {
+	item, score, err := ParseWithScore[generatedTestItem](`{"name": "bolt", "COUNT": "12"}`)
+	if err != nil {
+		t.Fatalf("ParseWithScore failed: %v", err)
+	}
+	if item.Name != "bolt" || item.Count != 12 {
+		t.Errorf("unexpected item: %+v", item)
+	}
+	flags := score.Flags()
+	if flags[string(FlagFuzzyFieldMatch)] == 0 || flags[string(FlagStringToInt)] == 0 {
+		t.Errorf("expected fuzzy match and string-to-int flags, got %v", flags)
+	}
+
+	_, err = Parse[generatedTestItem](`{"count": 1}`)
+	if err == nil || !strings.Contains(err.Error(), "name: missing required field") {
+		t.Errorf("expected missing name, got %v", err)
+	}
+}
+
+func TestRegisterGeneratedOutOfDate(t *testing.T) {
+	type item struct {
+		Name  string
+		Price float64
+	}
+	tests := []struct {
+		name   string
+		fields []string
+	}{
+		{"missing field", []string{"Name"}},
+		{"renamed field", []string{"Name", "Cost"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				r := recover()
+				if r == nil || !strings.Contains(r.(string), "out of date") {
+					t.Errorf("expected an out of date panic, got %v", r)
+				}
+			}()
+			RegisterGenerated(func(st *StructState) (v item) { return v }, tt.fields...)
+		})
+	}
+}
+
+func TestGeneratedTypedFields(t *testing.T) {
+	inputs := []string{
+		`{"name": "a", "count": 3, "size": 7, "ratio": 0.5, "on": true, "sentiment": "positive", "item": {"name": "bolt", "count": 2}}`,
+		`{"NAME": "b", "Count": 2.7, "size": 300, "Ratio": 1e40, "on": "yes", "sentiment": "POSITIVE", "item": {"count": 1.5}}`,
+		`{"name": "N/A", "count": -4, "size": "12", "ratio": "3.5", "on": 1, "sentiment": null, "item": "none"}`,
+		`{"name": 42, "count": "many", "on": null, "item": {"name": null, "COUNT": "4"}}`,
+	}
+	for i, input := range inputs {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			genValue, gen, genErr := ParseDetailed[generatedTestKinds](input)
+			refValue, ref, refErr := ParseDetailed[generatedTestKindsReflected](input)
+
+			if fmt.Sprint(genErr) != fmt.Sprint(refErr) {
+				t.Fatalf("errors differ:\ngenerated: %v\nreflected: %v", genErr, refErr)
+			}
+			if genValue != generatedTestKinds(refValue) {
+				t.Errorf("values differ:\ngenerated: %+v\nreflected: %+v", genValue, refValue)
+			}
+			if !reflect.DeepEqual(gen.Score.Flags(), ref.Score.Flags()) {
+				t.Errorf("flags differ:\ngenerated: %v\nreflected: %v", gen.Score.Flags(), ref.Score.Flags())
+			}
+			if !reflect.DeepEqual(gen.Presence, ref.Presence) {
+				t.Errorf("presence differs:\ngenerated: %v\nreflected: %v", gen.Presence, ref.Presence)
+			}
+		})
+	}
+}
+
+func TestCoerceGenerated(t *testing.T) {
+	raw := map[string]interface{}{"name": "a", "count": -1.5, "item": map[string]interface{}{"count": 2.0}}
+	score := &Score{}
+	v, err := CoerceGenerated(raw, score, coerceGeneratedTestKindsFields)
+	if err == nil || !strings.Contains(err.Error(), "count: ") || !strings.Contains(err.Error(), "item.name: missing required field") {
+		t.Errorf("expected count and item.name errors, got %v", err)
+	}
+	if v.Name != "a" || v.Count != 0 || v.Item.Count != 2 {
+		t.Errorf("unexpected value: %+v", v)
+	}
+	if score.Flags()[string(FlagFloatToInt)] == 0 {
+		t.Errorf("expected FloatToInt flag, got %v", score.Flags())
+	}
+
+	// Anything other than an object goes through Coerce
+	if _, err := CoerceGenerated("nope", nil, coerceGeneratedTestKindsFields); err == nil {
+		t.Error("expected an error for a string")
+	}
+}
+
+func TestCoerce(t *testing.T) {
+	score := &Score{}
+	n, err := Coerce[int]("**42**", score)
+	if err != nil || n != 42 {
+		t.Fatalf("expected 42, got %d, %v", n, err)
+	}
+	if score.Flags()[string(FlagMarkdownStripped)] == 0 {
+		t.Errorf("expected flags to be added to score, got %v", score.Flags())
+	}
+}
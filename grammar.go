@@ -0,0 +1,351 @@
+package sap
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// grammarPrimitives holds the GBNF rules for JSON primitives, in the order
+// they are written.
+var grammarPrimitives = []struct{ name, body string }{
+	{"value", `object | array | string | number | boolean | null`},
+	{"object", `"{" ws ( string ":" ws value ( "," ws string ":" ws value )* )? "}" ws`},
+	{"array", `"[" ws ( value ( "," ws value )* )? "]" ws`},
+	{"string", `"\"" ( [^"\\\x7F\x00-\x1F] | "\\" ( ["\\/bfnrt] | "u" [0-9a-fA-F]{4} ) )* "\"" ws`},
+	{"number", `"-"? ( [0-9] | [1-9] [0-9]* ) ( "." [0-9]+ )? ( [eE] [-+]? [0-9]+ )? ws`},
+	{"integer", `"-"? ( [0-9] | [1-9] [0-9]* ) ws`},
+	{"unsigned", `( [0-9] | [1-9] [0-9]* ) ws`},
+	{"boolean", `( "true" | "false" ) ws`},
+	{"null", `"null" ws`},
+	{"date-time", `"\"" date-part "T" time-part ( "Z" | [+-] [0-9]{2} ":" [0-9]{2} ) "\"" ws`},
+	{"date", `"\"" date-part "\"" ws`},
+	{"date-part", `[0-9]{4} "-" ( "0" [1-9] | "1" [0-2] ) "-" ( "0" [1-9] | [12] [0-9] | "3" [01] )`},
+	{"time-part", `( [01] [0-9] | "2" [0-3] ) ":" [0-5] [0-9] ":" [0-5] [0-9] ( "." [0-9]+ )?`},
+	{"uuid", `"\"" [0-9a-fA-F]{8} "-" [0-9a-fA-F]{4} "-" [0-9a-fA-F]{4} "-" [0-9a-fA-F]{4} "-" [0-9a-fA-F]{12} "\"" ws`},
+	{"ws", `[ \t\n]{0,20}`},
+}
+
+// grammarDeps lists the primitive rules each primitive rule refers to.
+var grammarDeps = map[string][]string{
+	"value":     {"object", "array", "string", "number", "boolean", "null"},
+	"object":    {"string", "value"},
+	"array":     {"value"},
+	"date-time": {"date-part", "time-part"},
+	"date":      {"date-part"},
+}
+
+// Grammar returns a GBNF grammar, as used by llama.cpp and compatible
+// runtimes for constrained decoding, that matches the JSON Parse[T]
+// expects:
+//
+//	grammar, err := sap.Grammar[Order]()
+//	// pass grammar with the completion request, then
+//	order, err := sap.Parse[Order](completion)
+//
+// Objects have every field in declaration order under the name Parse
+// matches first, registered enums and enum= options allow only their
+// values, unions allow any of their variants, pointers also allow null,
+// and time.Time fields and format=date-time, date and uuid strings must be
+// well formed. Array lengths follow len= and fixed-size arrays; other
+// constraints, such as min, max and pattern, are left to parsing.
+//
+// Generation fails, naming the field, if a field's type has no JSON form,
+// such as a channel or func, or its `gsap` tag is malformed.
+func Grammar[T any]() (string, error) {
+	return grammarFor(reflect.TypeOf((*T)(nil)).Elem())
+}
+
+// grammarFor returns the grammar for t.
+func grammarFor(t reflect.Type) (string, error) {
+	g := &grammarBuilder{
+		names: make(map[grammarKey]string),
+		taken: map[string]bool{"root": true},
+		used:  make(map[string]bool),
+	}
+	for _, p := range grammarPrimitives {
+		g.taken[p.name] = true
+	}
+
+	root, err := g.expr(t, fieldOptions{}, "")
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "root ::= %s\n", root)
+	for _, rule := range g.rules {
+		fmt.Fprintf(&b, "%s ::= %s\n", rule.name, rule.body)
+	}
+	g.use("ws")
+	for _, p := range grammarPrimitives {
+		if g.used[p.name] {
+			fmt.Fprintf(&b, "%s ::= %s\n", p.name, p.body)
+		}
+	}
+	return b.String(), nil
+}
+
+// grammarKey identifies the rule for a struct type, which differs for a
+// variant of a discriminated union.
+type grammarKey struct {
+	t        reflect.Type
+	key, tag string // discriminator and its value, if a union variant
+}
+
+// grammarRule is a named rule for a struct type.
+type grammarRule struct {
+	name, body string
+}
+
+// grammarBuilder collects the rules of a grammar.
+type grammarBuilder struct {
+	names map[grammarKey]string // rule name of each struct type
+	taken map[string]bool       // rule names in use
+	rules []grammarRule         // struct rules, in the order found
+	used  map[string]bool       // primitive rules referred to
+}
+
+// use marks a primitive rule and those it refers to as used.
+func (g *grammarBuilder) use(name string) string {
+	if !g.used[name] {
+		g.used[name] = true
+		for _, dep := range grammarDeps[name] {
+			g.use(dep)
+		}
+	}
+	return name
+}
+
+// expr returns a GBNF expression for values of type t in a field with the
+// given options. Every expression consumes the whitespace after it.
+func (g *grammarBuilder) expr(t reflect.Type, opts fieldOptions, path string) (string, error) {
+	shape, err := shapeOf(t, opts)
+	if err != nil {
+		return "", describeError("generate grammar", path, err)
+	}
+
+	switch shape.kind {
+	case shapeTime:
+		return g.use("date-time"), nil
+	case shapeText:
+		return g.use("string"), nil
+	case shapeRaw, shapeAny:
+		return g.use("value"), nil
+	case shapePointer:
+		elem, err := g.expr(t.Elem(), opts, path)
+		if err != nil {
+			return "", err
+		}
+		if strings.HasSuffix(elem, " | null )") {
+			return elem, nil
+		}
+		return "( " + elem + " | " + g.use("null") + " )", nil
+	case shapeUnion:
+		return g.union(shape.union, path)
+	case shapeEnum:
+		return g.enum(shape.enum), nil
+	case shapeString:
+		switch opts.format {
+		case "date-time", "date", "uuid":
+			return g.use(opts.format), nil
+		}
+		return g.use("string"), nil
+	case shapeInt:
+		return g.use("integer"), nil
+	case shapeUint:
+		return g.use("unsigned"), nil
+	case shapeFloat:
+		return g.use("number"), nil
+	case shapeBool:
+		return g.use("boolean"), nil
+	case shapeList:
+		elem, err := g.expr(t.Elem(), opts, path+"[]")
+		if err != nil {
+			return "", err
+		}
+		if t.Kind() == reflect.Array {
+			return g.list(elem, t.Len(), t.Len()), nil
+		}
+		lo, hi := 0, -1
+		if opts.minLen != nil {
+			lo = *opts.minLen
+		}
+		if opts.maxLen != nil {
+			hi = *opts.maxLen
+		}
+		return g.list(elem, lo, hi), nil
+	case shapeMap:
+		elem, err := g.expr(t.Elem(), fieldOptions{}, path+"{}")
+		if err != nil {
+			return "", err
+		}
+		entry := g.use("string") + ` ":" ws ` + elem
+		return `"{" ws ( ` + entry + ` ( "," ws ` + entry + ` )* )? "}" ws`, nil
+	default: // shapeStruct
+		return g.structRule(grammarKey{t: t}, path)
+	}
+}
+
+// list returns an expression for a JSON array of lo to hi elements; hi is
+// -1 for no limit.
+func (g *grammarBuilder) list(elem string, lo, hi int) string {
+	if hi == 0 {
+		return `"[" ws "]" ws`
+	}
+	rest := `( "," ws ` + elem + ` )`
+	switch {
+	case hi < 0 && lo <= 1:
+		rest += "*"
+	case hi < 0:
+		rest += "{" + strconv.Itoa(lo-1) + ",}"
+	case lo == hi:
+		rest += "{" + strconv.Itoa(lo-1) + "}"
+	case lo <= 1:
+		rest += "{0," + strconv.Itoa(hi-1) + "}"
+	default:
+		rest += "{" + strconv.Itoa(lo-1) + "," + strconv.Itoa(hi-1) + "}"
+	}
+	items := elem + " " + rest
+	if lo == 0 {
+		items = "( " + items + " )?"
+	}
+	return `"[" ws ` + items + ` "]" ws`
+}
+
+// enum returns an expression matching the names of an enum.
+func (g *grammarBuilder) enum(def *enumDef) string {
+	literals := make([]string, len(def.names))
+	for i, name := range def.names {
+		literals[i] = gbnfLiteral(jsonString(name))
+	}
+	return "( " + strings.Join(literals, " | ") + " ) " + g.use("ws")
+}
+
+// union returns an expression matching any variant of a union. The
+// variants of a discriminated union start with their discriminator.
+func (g *grammarBuilder) union(def *unionDef, path string) (string, error) {
+	var tags []string
+	if def.discriminator != "" {
+		tags = def.tagNames()
+	}
+
+	variants := make([]string, len(def.variants))
+	for i, variant := range def.variants {
+		for variant.Kind() == reflect.Ptr {
+			variant = variant.Elem()
+		}
+		var s string
+		var err error
+		if tags != nil && variant.Kind() == reflect.Struct {
+			s, err = g.structRule(grammarKey{t: variant, key: def.discriminator, tag: tags[i]}, path)
+		} else {
+			s, err = g.expr(variant, fieldOptions{}, path)
+		}
+		if err != nil {
+			return "", err
+		}
+		variants[i] = s
+	}
+	return "( " + strings.Join(variants, " | ") + " )", nil
+}
+
+// structRule returns the name of the rule for a struct type, adding the
+// rule the first time. The name is reserved before the fields are
+// visited, so recursive types refer to their own rule.
+func (g *grammarBuilder) structRule(k grammarKey, path string) (string, error) {
+	if name, ok := g.names[k]; ok {
+		return name, nil
+	}
+	base := ruleName(k.t.Name())
+	name := base
+	for i := 2; g.taken[name]; i++ {
+		name = base + "-" + strconv.Itoa(i)
+	}
+	g.taken[name] = true
+	g.names[k] = name
+	idx := len(g.rules)
+	g.rules = append(g.rules, grammarRule{name: name})
+
+	var members []string
+	if k.key != "" {
+		members = append(members, gbnfLiteral(jsonString(k.key))+` ws ":" ws `+gbnfLiteral(jsonString(k.tag))+" ws")
+	}
+	plan := structPlanFor(k.t)
+	for i := range plan.fields {
+		fp := &plan.fields[i]
+		if fp.opts.skip || (k.key != "" && fp.pathName == k.key) {
+			continue
+		}
+		fieldType, _ := fp.describedType()
+		value, err := g.expr(fieldType, fp.opts, joinSchemaPath(path, fp.pathName))
+		if err != nil {
+			return "", err
+		}
+		members = append(members, gbnfLiteral(jsonString(fp.pathName))+` ws ":" ws `+value)
+	}
+
+	body := `"{" ws "}" ws`
+	if len(members) > 0 {
+		body = "\"{\" ws (\n  " + strings.Join(members, ` "," ws`+"\n  ") + "\n) \"}\" ws"
+	}
+	g.rules[idx].body = body
+	return name, nil
+}
+
+// ruleName converts a Go name to a GBNF rule name, e.g. "line-item" for
+// LineItem.
+func ruleName(s string) string {
+	var b strings.Builder
+	prevLower := false
+	for _, r := range s {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			if unicode.IsUpper(r) && prevLower {
+				b.WriteByte('-')
+			}
+			b.WriteRune(unicode.ToLower(r))
+			prevLower = unicode.IsLower(r) || unicode.IsDigit(r)
+		default:
+			if b.Len() > 0 && prevLower {
+				b.WriteByte('-')
+			}
+			prevLower = false
+		}
+	}
+	name := strings.TrimSuffix(b.String(), "-")
+	if name == "" {
+		return "type"
+	}
+	return name
+}
+
+// jsonString returns s as a JSON string literal.
+func jsonString(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
+
+// gbnfLiteral returns a GBNF literal matching s exactly.
+func gbnfLiteral(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r < 0x20:
+			fmt.Fprintf(&b, `\x%02X`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,382 @@
+package sap
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// gbnfNode is an element of a GBNF rule, for matching inputs in tests.
+type gbnfNode struct {
+	lit      []rune          // literal
+	class    func(rune) bool // character class
+	ref      string          // rule reference
+	alts     [][]gbnfNode    // group
+	min, max int             // repetition; max -1 for no limit
+}
+
+// gbnfGrammar is a parsed GBNF grammar.
+type gbnfGrammar map[string][][]gbnfNode
+
+var reGBNFRule = regexp.MustCompile(`(?m)^([a-z0-9-]+) ::= `)
+
+// parseGBNF parses the subset of GBNF that Grammar writes.
+func parseGBNF(t *testing.T, text string) gbnfGrammar {
+	t.Helper()
+	g := make(gbnfGrammar)
+	locs := reGBNFRule.FindAllStringSubmatchIndex(text, -1)
+	for i, loc := range locs {
+		end := len(text)
+		if i+1 < len(locs) {
+			end = locs[i+1][0]
+		}
+		p := &gbnfParser{src: []rune(text[loc[1]:end])}
+		alts := p.alts()
+		p.space()
+		if p.pos != len(p.src) {
+			t.Fatalf("rule %s: unexpected %q", text[loc[2]:loc[3]], string(p.src[p.pos:]))
+		}
+		g[text[loc[2]:loc[3]]] = alts
+	}
+	return g
+}
+
+type gbnfParser struct {
+	src []rune
+	pos int
+}
+
+func (p *gbnfParser) space() {
+	for p.pos < len(p.src) && strings.ContainsRune(" \t\n", p.src[p.pos]) {
+		p.pos++
+	}
+}
+
+func (p *gbnfParser) alts() [][]gbnfNode {
+	alts := [][]gbnfNode{p.seq()}
+	for p.space(); p.pos < len(p.src) && p.src[p.pos] == '|'; p.space() {
+		p.pos++
+		alts = append(alts, p.seq())
+	}
+	return alts
+}
+
+func (p *gbnfParser) seq() []gbnfNode {
+	var seq []gbnfNode
+	for {
+		p.space()
+		if p.pos == len(p.src) || p.src[p.pos] == '|' || p.src[p.pos] == ')' {
+			return seq
+		}
+		n := gbnfNode{min: 1, max: 1}
+		switch c := p.src[p.pos]; {
+		case c == '"':
+			p.pos++
+			for p.src[p.pos] != '"' {
+				n.lit = append(n.lit, p.char())
+			}
+			p.pos++
+		case c == '[':
+			p.pos++
+			negate := p.src[p.pos] == '^'
+			if negate {
+				p.pos++
+			}
+			var ranges [][2]rune
+			for p.src[p.pos] != ']' {
+				lo := p.char()
+				hi := lo
+				if p.src[p.pos] == '-' && p.src[p.pos+1] != ']' {
+					p.pos++
+					hi = p.char()
+				}
+				ranges = append(ranges, [2]rune{lo, hi})
+			}
+			p.pos++
+			n.class = func(r rune) bool {
+				for _, rg := range ranges {
+					if r >= rg[0] && r <= rg[1] {
+						return !negate
+					}
+				}
+				return negate
+			}
+		case c == '(':
+			p.pos++
+			n.alts = p.alts()
+			p.space()
+			p.pos++ // ')'
+		default:
+			start := p.pos
+			for p.pos < len(p.src) && (p.src[p.pos] == '-' || p.src[p.pos] >= 'a' && p.src[p.pos] <= 'z' || p.src[p.pos] >= '0' && p.src[p.pos] <= '9') {
+				p.pos++
+			}
+			n.ref = string(p.src[start:p.pos])
+		}
+		if p.pos < len(p.src) {
+			switch p.src[p.pos] {
+			case '?':
+				n.min, n.max = 0, 1
+				p.pos++
+			case '*':
+				n.min, n.max = 0, -1
+				p.pos++
+			case '+':
+				n.min, n.max = 1, -1
+				p.pos++
+			case '{':
+				end := p.pos + strings.IndexRune(string(p.src[p.pos:]), '}')
+				lo, hi, comma := strings.Cut(string(p.src[p.pos+1:end]), ",")
+				n.min, _ = strconv.Atoi(lo)
+				n.max = n.min
+				if comma {
+					n.max = -1
+					if hi != "" {
+						n.max, _ = strconv.Atoi(hi)
+					}
+				}
+				p.pos = end + 1
+			}
+		}
+		seq = append(seq, n)
+	}
+}
+
+// char reads one possibly escaped character of a literal or class.
+func (p *gbnfParser) char() rune {
+	c := p.src[p.pos]
+	p.pos++
+	if c != '\\' {
+		return c
+	}
+	c = p.src[p.pos]
+	p.pos++
+	switch c {
+	case 'n':
+		return '\n'
+	case 't':
+		return '\t'
+	case 'x':
+		v, _ := strconv.ParseUint(string(p.src[p.pos:p.pos+2]), 16, 32)
+		p.pos += 2
+		return rune(v)
+	}
+	return c
+}
+
+// matches reports whether the grammar's root rule matches all of s.
+func (g gbnfGrammar) matches(s string) bool {
+	in := []rune(s)
+	return g.alts(g["root"], in, 0, func(pos int) bool { return pos == len(in) })
+}
+
+func (g gbnfGrammar) alts(alts [][]gbnfNode, in []rune, pos int, k func(int) bool) bool {
+	for _, seq := range alts {
+		if g.seq(seq, in, pos, k) {
+			return true
+		}
+	}
+	return false
+}
+
+func (g gbnfGrammar) seq(seq []gbnfNode, in []rune, pos int, k func(int) bool) bool {
+	if len(seq) == 0 {
+		return k(pos)
+	}
+	return g.repeat(seq[0], 0, in, pos, func(next int) bool { return g.seq(seq[1:], in, next, k) })
+}
+
+// repeat matches n after it has matched count times, greedily. Once the
+// minimum is met, matches must make progress, so that empty matches can't
+// repeat forever.
+func (g gbnfGrammar) repeat(n gbnfNode, count int, in []rune, pos int, k func(int) bool) bool {
+	if n.max < 0 || count < n.max {
+		more := func(next int) bool {
+			return (next > pos || count < n.min) && g.repeat(n, count+1, in, next, k)
+		}
+		if g.once(n, in, pos, more) {
+			return true
+		}
+	}
+	return count >= n.min && k(pos)
+}
+
+func (g gbnfGrammar) once(n gbnfNode, in []rune, pos int, k func(int) bool) bool {
+	switch {
+	case n.lit != nil:
+		if pos+len(n.lit) > len(in) || string(in[pos:pos+len(n.lit)]) != string(n.lit) {
+			return false
+		}
+		return k(pos + len(n.lit))
+	case n.class != nil:
+		return pos < len(in) && n.class(in[pos]) && k(pos+1)
+	case n.alts != nil:
+		return g.alts(n.alts, in, pos, k)
+	}
+	return g.alts(g[n.ref], in, pos, k)
+}
+
+// checkGrammar generates and parses a grammar and checks that every rule
+// it refers to is defined.
+func checkGrammar(t *testing.T, grammar func() (string, error)) gbnfGrammar {
+	t.Helper()
+	text, err := grammar()
+	if err != nil {
+		t.Fatalf("Grammar failed: %v", err)
+	}
+	g := parseGBNF(t, text)
+	var walk func(alts [][]gbnfNode)
+	walk = func(alts [][]gbnfNode) {
+		for _, seq := range alts {
+			for _, n := range seq {
+				if n.ref != "" && g[n.ref] == nil {
+					t.Errorf("undefined rule %q in grammar:\n%s", n.ref, text)
+				}
+				walk(n.alts)
+			}
+		}
+	}
+	for _, alts := range g {
+		walk(alts)
+	}
+	return g
+}
+
+type testGrammarOrder struct {
+	ID       string            `json:"id"`
+	Status   string            `json:"status" gsap:"enum=open|closed"`
+	Priority testPriority      `json:"priority"`
+	Count    uint              `json:"count"`
+	Price    float64           `json:"price"`
+	Paid     bool              `json:"paid"`
+	Codes    []string          `json:"codes" gsap:"len=1..3"`
+	Pair     [2]int            `json:"pair"`
+	Notes    *string           `json:"notes"`
+	Placed   time.Time         `json:"placed"`
+	Due      string            `json:"due" gsap:"format=date"`
+	Extra    map[string]string `json:"extra"`
+	Any      interface{}       `json:"any"`
+	Internal string            `json:"-"`
+	TestMeta
+}
+
+func TestGrammarMatches(t *testing.T) {
+	g := checkGrammar(t, Grammar[testGrammarOrder])
+
+	valid := `{"id": "A-1", "status": "open", "priority": "High", "count": 2, "price": -1.5e3, "paid": true,
+		"codes": ["x", "y\"z"], "pair": [1, 2], "notes": null, "placed": "2024-01-02T03:04:05.123+02:00",
+		"due": "2024-12-31", "extra": {}, "any": [1, {"a": null}], "created_by": "ann"}`
+	if !g.matches(valid) {
+		t.Fatalf("grammar rejects valid input")
+	}
+	if _, err := Parse[testGrammarOrder](valid); err != nil {
+		t.Errorf("Parse rejects input the grammar accepts: %v", err)
+	}
+
+	tests := []struct {
+		name, from, to string
+	}{
+		{"unknown enum", `"open"`, `"pending"`},
+		{"int enum as number", `"High"`, `2`},
+		{"negative unsigned", `"count": 2`, `"count": -2`},
+		{"too many codes", `["x", "y\"z"]`, `["a", "b", "c", "d"]`},
+		{"no codes", `["x", "y\"z"]`, `[]`},
+		{"short array", `[1, 2]`, `[1]`},
+		{"bad time", `03:04:05.123`, `3:04`},
+		{"bad date", `"2024-12-31"`, `"31/12/2024"`},
+		{"missing field", `"paid": true,`, ``},
+		{"reordered", `"id": "A-1", "status": "open"`, `"status": "open", "id": "A-1"`},
+		{"skipped field", `"created_by": "ann"`, `"created_by": "ann", "Internal": ""`},
+		{"trailing comma", `"ann"}`, `"ann",}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := strings.Replace(valid, tt.from, tt.to, 1)
+			if input == valid {
+				t.Fatalf("replacement %q not found", tt.from)
+			}
+			if g.matches(input) {
+				t.Errorf("grammar accepts %s", input)
+			}
+		})
+	}
+}
+
+func TestGrammarRequiredPointer(t *testing.T) {
+	g := checkGrammar(t, Grammar[testSchemaTicket])
+
+	valid := `{"owner": "ann", "reviewer": null, "urgency": "High", "labels": ["bug"]}`
+	if !g.matches(valid) {
+		t.Fatalf("grammar rejects valid input")
+	}
+	if input := strings.Replace(valid, `"ann"`, `null`, 1); g.matches(input) {
+		t.Errorf("grammar accepts null for a required pointer: %s", input)
+	}
+	if _, err := Parse[testSchemaTicket](strings.Replace(valid, `"ann"`, `null`, 1)); err == nil {
+		t.Errorf("Parse accepts null for a required pointer")
+	}
+}
+
+func TestGrammarValues(t *testing.T) {
+	tests := []struct {
+		name    string
+		grammar func() (string, error)
+		value   interface{} // marshaled, or a JSON string
+	}{
+		{"slice of structs", Grammar[[]TestUser], []TestUser{{Name: "Ann", Age: 30}, {Name: "Bo\n"}}},
+		{"recursive", Grammar[testSchemaNode], testSchemaNode{
+			Value:    1,
+			Children: []*testSchemaNode{nil, {Value: 2, Children: []*testSchemaNode{}}},
+			Next:     &testSchemaList{},
+		}},
+		{"tagged union", Grammar[testToolCall], `{"tool": {"type": "search", "query": "go"}}`},
+		{"untagged union", Grammar[testDrawing], `{"title": "t", "main": {"side": 2}, "others": [{"width": 1, "height": 2}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := checkGrammar(t, tt.grammar)
+			data, ok := tt.value.(string)
+			if !ok {
+				b, err := json.Marshal(tt.value)
+				if err != nil {
+					t.Fatal(err)
+				}
+				data = string(b)
+			}
+			var indented bytes.Buffer
+			if err := json.Indent(&indented, []byte(data), "", "  "); err != nil {
+				t.Fatal(err)
+			}
+			for _, input := range []string{data, indented.String()} {
+				if !g.matches(input) {
+					t.Errorf("grammar rejects %s", input)
+				}
+			}
+		})
+	}
+
+	g := checkGrammar(t, Grammar[testToolCall])
+	if g.matches(`{"tool": {"type": "search", "expression": "1+1"}}`) {
+		t.Errorf("grammar accepts fields of the wrong variant")
+	}
+}
+
+func TestGrammarErrors(t *testing.T) {
+	type badChan struct {
+		Events chan int `json:"events"`
+	}
+	type badTag struct {
+		Age int `json:"age" gsap:"min=old"`
+	}
+
+	if _, err := Grammar[badChan](); err == nil || !strings.Contains(err.Error(), "events") {
+		t.Errorf("expected error for chan field, got %v", err)
+	}
+	if _, err := Grammar[badTag](); err == nil || !strings.Contains(err.Error(), "age") {
+		t.Errorf("expected error for invalid tag, got %v", err)
+	}
+}
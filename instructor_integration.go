@@ -22,13 +22,13 @@ import (
 //	    instructor.WithParser(parser),
 //	)
 type InstructorParser struct {
-	parser *sapParser
+	parser *Parser
 }
 
 // NewInstructorParser creates a new instructor-go compatible parser using SAP
-func NewInstructorParser() *InstructorParser {
+func NewInstructorParser(opts ...Option) *InstructorParser {
 	return &InstructorParser{
-		parser: NewParser(),
+		parser: New(opts...),
 	}
 }
 
@@ -49,24 +49,23 @@ func (ip *InstructorParser) Unmarshal(data []byte, v interface{}) error {
 
 	// Parse the response
 	result, err := ip.parser.Parse(string(data), targetType)
-	if err != nil {
-		return err
-	}
 
-	// Set the result into v
-	reflect.ValueOf(v).Elem().Set(reflect.ValueOf(result))
-	return nil
+	// Set the result into v, including a partial result from a *ParseError
+	if result != nil {
+		reflect.ValueOf(v).Elem().Set(reflect.ValueOf(result))
+	}
+	return err
 }
 
 // WithStrict creates a new parser in strict mode
 func (ip *InstructorParser) WithStrict(strict bool) *InstructorParser {
-	ip.parser.WithStrict(strict)
+	ip.parser = ip.parser.WithStrict(strict)
 	return ip
 }
 
 // WithIncompleteJSON allows incomplete JSON for streaming
 func (ip *InstructorParser) WithIncompleteJSON(allow bool) *InstructorParser {
-	ip.parser.WithIncompleteJSON(allow)
+	ip.parser = ip.parser.WithIncompleteJSON(allow)
 	return ip
 }
 
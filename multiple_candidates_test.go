@@ -258,3 +258,22 @@ Please review and confirm.`
 		t.Errorf("Expected name 'Data Corp', got '%s'", result.Name)
 	}
 }
+
+// TestNestedCandidateDoesNotWin tests that a nested object, which the
+// extractor also offers as a candidate, doesn't beat the document around it
+// when the document needed more coercions
+func TestNestedCandidateDoesNotWin(t *testing.T) {
+	input := `Here you go: {title: 'Launch', status: 'active', tasks: "design, build", priority: "2", owner: {"name": "Eve"}}`
+
+	result, err := Parse[Project](input)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if result.Title != "Launch" {
+		t.Errorf("Expected title 'Launch', got '%s'", result.Title)
+	}
+	if len(result.Tasks) != 2 {
+		t.Errorf("Expected 2 tasks, got %v", result.Tasks)
+	}
+}
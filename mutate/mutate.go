@@ -0,0 +1,393 @@
+// Package mutate corrupts valid JSON the way language models do, for
+// testing that a parser recovers the original value.
+//
+// Each Mutation is a kind of damage the sap package repairs: syntax that
+// FixJSON fixes, wrapping the extractor strips and values the type coercer
+// converts back. A typical property test marshals a value, corrupts it and
+// checks that parsing returns the value again:
+//
+//	r := rand.New(rand.NewSource(seed))
+//	doc, _ := json.Marshal(order)
+//	text, applied, err := mutate.Random(string(doc), mutate.Lossless, r)
+//	got, err := sap.Parse[Order](text)
+//	// got should equal order; report applied and text if not
+//
+// The value mutations assume the document was marshaled from the type it
+// is parsed into: numbers are turned into strings like "30 units", which
+// only a numeric field converts back, and null into strings like "N/A",
+// which only a pointer, slice or map field reads as null.
+package mutate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"regexp"
+	"strings"
+)
+
+// Mutation is a set of corruptions, combined with |.
+type Mutation uint
+
+const (
+	// UnquotedKeys leaves object keys that are identifiers unquoted:
+	// {name: "Ann"}.
+	UnquotedKeys Mutation = 1 << iota
+	// SingleQuotes quotes keys and strings with single quotes: {'name': 'Ann'}.
+	SingleQuotes
+	// TrailingCommas adds a comma after the last member of objects and
+	// arrays: [1, 2,].
+	TrailingCommas
+	// Comments adds // comments before object members and /* */ comments
+	// after values.
+	Comments
+	// MarkdownFence wraps the document in a ```json code block.
+	MarkdownFence
+	// Preamble surrounds the document with prose, as in "Sure! Here is the
+	// JSON you asked for:".
+	Preamble
+	// UnitSuffixes writes numbers as strings with units, currency symbols
+	// or thousands separators: "30 years", "$1,200".
+	UnitSuffixes
+	// NullStrings writes null as a null-like string: "N/A", "none".
+	NullStrings
+	// Truncate cuts the document off part way through, as when a response
+	// hits its token limit.
+	Truncate
+)
+
+// Lossless holds the mutations that keep every value in the document, so
+// parsing the result returns the original value.
+const Lossless = UnquotedKeys | SingleQuotes | TrailingCommas | Comments |
+	MarkdownFence | Preamble | UnitSuffixes | NullStrings
+
+// All holds every mutation.
+const All = Lossless | Truncate
+
+var mutationNames = []string{
+	"UnquotedKeys",
+	"SingleQuotes",
+	"TrailingCommas",
+	"Comments",
+	"MarkdownFence",
+	"Preamble",
+	"UnitSuffixes",
+	"NullStrings",
+	"Truncate",
+}
+
+// String returns the names of the mutations in m joined by "|", e.g.
+// "UnquotedKeys|Comments".
+func (m Mutation) String() string {
+	if m == 0 {
+		return "none"
+	}
+	var names []string
+	for i, name := range mutationNames {
+		if m&(1<<i) != 0 {
+			names = append(names, name)
+			m &^= 1 << i
+		}
+	}
+	if m != 0 {
+		names = append(names, fmt.Sprintf("Mutation(%#x)", uint(m)))
+	}
+	return strings.Join(names, "|")
+}
+
+// Phrases the mutations choose from; the first is used without a source
+// of randomness. None contain quotes or brackets, which would make the
+// damage something no parser could be expected to undo.
+var (
+	lineComments  = []string{"// filled in from the request", "// TODO: double-check", "// see above"}
+	blockComments = []string{"/* estimated */", "/* from the source text */", "/* optional */"}
+	nullStrings   = []string{"N/A", "null", "none", "unknown"}
+	units         = []string{"units", "years", "USD", "kg", "%"}
+	preambles     = []string{
+		"Sure! Here is the JSON you asked for:",
+		"Here's the extracted data:",
+		"Based on the text, the result is:",
+	}
+	postscripts = []string{
+		"Let me know if you need any changes.",
+		"I filled in every field I could find.",
+	}
+)
+
+// reIdentifier matches keys that can be left unquoted.
+var reIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Apply returns doc, a JSON document, corrupted by the mutations in m. It
+// is re-indented by two spaces either way.
+//
+// r chooses which keys, values and members are corrupted and how. With a
+// nil r every one that can be is, in the same way each time, and Truncate
+// cuts the document in half.
+func Apply(doc string, m Mutation, r *rand.Rand) (string, error) {
+	root, err := decode(doc)
+	if err != nil {
+		return "", err
+	}
+	mu := &mutator{m: m, r: r}
+	mu.value(root, 0)
+	text := mu.buf.String()
+
+	if m&Truncate != 0 {
+		text = mu.truncate(text)
+	}
+	if m&MarkdownFence != 0 {
+		text = "```json\n" + text
+		if m&Truncate == 0 {
+			text += "\n```"
+		}
+	}
+	if m&Preamble != 0 {
+		text = mu.choose(preambles) + "\n\n" + text
+		if m&Truncate == 0 && mu.chance() {
+			text += "\n\n" + mu.choose(postscripts)
+		}
+	}
+	return text, nil
+}
+
+// Random applies a random, nonempty subset of the mutations in m to doc
+// and returns the result and the subset it applied.
+func Random(doc string, m Mutation, r *rand.Rand) (string, Mutation, error) {
+	if m == 0 {
+		return "", 0, errors.New("mutate: no mutations to choose from")
+	}
+	var bits []Mutation
+	for bit := Mutation(1); bit != 0 && bit <= m; bit <<= 1 {
+		if m&bit != 0 {
+			bits = append(bits, bit)
+		}
+	}
+	var applied Mutation
+	for _, bit := range bits {
+		if r.Intn(2) == 0 {
+			applied |= bit
+		}
+	}
+	if applied == 0 {
+		applied = bits[r.Intn(len(bits))]
+	}
+	text, err := Apply(doc, applied, r)
+	return text, applied, err
+}
+
+// node is a decoded JSON value.
+type node struct {
+	keys  []string // object keys, in order
+	elems []*node  // object values or array elements; nil for scalars
+	array bool
+	text  string      // JSON text of a scalar
+	str   *string     // value of a string
+	num   json.Number // value of a number
+	null  bool
+}
+
+// decode parses doc into a tree that keeps the order of object keys.
+func decode(doc string) (*node, error) {
+	dec := json.NewDecoder(strings.NewReader(doc))
+	dec.UseNumber()
+	root, err := decodeValue(dec)
+	if err != nil {
+		return nil, fmt.Errorf("mutate: invalid JSON: %w", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("mutate: invalid JSON: data after the top-level value")
+	}
+	return root, nil
+}
+
+func decodeValue(dec *json.Decoder) (*node, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch v := tok.(type) {
+	case json.Delim:
+		n := &node{array: v == '[', elems: []*node{}}
+		for dec.More() {
+			if !n.array {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				n.keys = append(n.keys, key.(string))
+			}
+			elem, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			n.elems = append(n.elems, elem)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return n, nil
+	case string:
+		return &node{text: quote(v), str: &v}, nil
+	case json.Number:
+		return &node{text: v.String(), num: v}, nil
+	case bool:
+		return &node{text: fmt.Sprint(v)}, nil
+	}
+	return &node{text: "null", null: true}, nil
+}
+
+// mutator writes a corrupted document.
+type mutator struct {
+	m   Mutation
+	r   *rand.Rand
+	buf strings.Builder
+}
+
+// chance reports whether to corrupt the next site.
+func (mu *mutator) chance() bool {
+	return mu.r == nil || mu.r.Intn(2) == 0
+}
+
+// choose returns one of options.
+func (mu *mutator) choose(options []string) string {
+	if mu.r == nil {
+		return options[0]
+	}
+	return options[mu.r.Intn(len(options))]
+}
+
+func (mu *mutator) newline(indent int) {
+	mu.buf.WriteByte('\n')
+	mu.buf.WriteString(strings.Repeat("  ", indent))
+}
+
+func (mu *mutator) value(n *node, indent int) {
+	switch {
+	case n.elems != nil:
+		mu.composite(n, indent)
+	case n.str != nil:
+		mu.string(*n.str)
+	case n.num != "" && mu.m&UnitSuffixes != 0 && mu.chance():
+		if s, ok := mu.unitNumber(n.num); ok {
+			mu.string(s)
+			return
+		}
+		mu.buf.WriteString(n.text)
+	case n.null && mu.m&NullStrings != 0 && mu.chance():
+		mu.string(mu.choose(nullStrings))
+	default:
+		mu.buf.WriteString(n.text)
+	}
+	if n.elems == nil && mu.m&Comments != 0 && mu.chance() {
+		mu.buf.WriteString(" " + mu.choose(blockComments))
+	}
+}
+
+func (mu *mutator) composite(n *node, indent int) {
+	open, close := "{", "}"
+	if n.array {
+		open, close = "[", "]"
+	}
+	mu.buf.WriteString(open)
+	if len(n.elems) == 0 {
+		mu.buf.WriteString(close)
+		return
+	}
+	for i, elem := range n.elems {
+		mu.newline(indent + 1)
+		if !n.array {
+			if mu.m&Comments != 0 && mu.chance() {
+				mu.buf.WriteString(mu.choose(lineComments))
+				mu.newline(indent + 1)
+			}
+			mu.key(n.keys[i])
+			mu.buf.WriteString(": ")
+		}
+		mu.value(elem, indent+1)
+		if i < len(n.elems)-1 || mu.m&TrailingCommas != 0 && mu.chance() {
+			mu.buf.WriteByte(',')
+		}
+	}
+	mu.newline(indent)
+	mu.buf.WriteString(close)
+}
+
+func (mu *mutator) key(k string) {
+	if mu.m&UnquotedKeys != 0 && reIdentifier.MatchString(k) && mu.chance() {
+		mu.buf.WriteString(k)
+		return
+	}
+	mu.string(k)
+}
+
+// string writes s as a JSON string, single-quoted if SingleQuotes allows.
+// Strings containing single quotes keep their double quotes, since models
+// rarely escape them correctly.
+func (mu *mutator) string(s string) {
+	text := quote(s)
+	if mu.m&SingleQuotes != 0 && !strings.ContainsRune(s, '\'') && mu.chance() {
+		text = "'" + text[1:len(text)-1] + "'"
+	}
+	mu.buf.WriteString(text)
+}
+
+// unitNumber writes num the way a model adds units to it, or reports false
+// if it is written in exponent form, which no unit reads well after.
+func (mu *mutator) unitNumber(num json.Number) (string, bool) {
+	s := num.String()
+	if strings.ContainsAny(s, "eE") {
+		return "", false
+	}
+	negative := strings.HasPrefix(s, "-")
+	whole, frac, hasFrac := strings.Cut(strings.TrimPrefix(s, "-"), ".")
+
+	variants := []string{"unit"}
+	if len(whole) > 3 {
+		variants = append(variants, "thousands")
+	}
+	if !negative {
+		variants = append(variants, "currency")
+	}
+	switch mu.choose(variants) {
+	case "thousands":
+		for i := len(whole) - 3; i > 0; i -= 3 {
+			whole = whole[:i] + "," + whole[i:]
+		}
+		s = whole
+		if hasFrac {
+			s += "." + frac
+		}
+		if negative {
+			s = "-" + s
+		}
+		return s, true
+	case "currency":
+		return "$" + s, true
+	}
+	unit := mu.choose(units)
+	if unit == "%" {
+		return s + unit, true
+	}
+	return s + " " + unit, true
+}
+
+// truncate cuts text, keeping at least its opening bracket.
+func (mu *mutator) truncate(text string) string {
+	runes := []rune(text)
+	if len(runes) < 2 {
+		return text
+	}
+	cut := len(runes) / 2
+	if mu.r != nil {
+		cut = 1 + mu.r.Intn(len(runes)-1)
+	}
+	return string(runes[:cut])
+}
+
+// quote returns s as a JSON string.
+func quote(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
@@ -0,0 +1,38 @@
+package sap
+
+// Option configures a Parser. Pass options to New, Parser.With, or any of
+// the generic Parse helpers.
+type Option func(*Parser)
+
+// WithStrict rejects malformed JSON instead of fixing it.
+func WithStrict() Option {
+	return func(p *Parser) { p.options.Strict = true }
+}
+
+// WithIncompleteJSON accepts truncated JSON, as from a response that is
+// still streaming. ParsePartial always accepts it.
+func WithIncompleteJSON() Option {
+	return func(p *Parser) { p.options.Streaming.AllowIncompleteJSON = true }
+}
+
+// WithMaxScore rejects candidates whose score is above n, so that a parse
+// needing too many coercions fails with ErrMaxScore instead. 0 means no
+// limit.
+func WithMaxScore(n int) Option {
+	return func(p *Parser) { p.options.MaxScore = n }
+}
+
+// WithRequiredPolicy sets which struct fields must be present.
+func WithRequiredPolicy(policy RequiredPolicy) Option {
+	return func(p *Parser) { p.options.Required = policy }
+}
+
+// WithConstraintPolicy sets what happens when a value breaks a constraint.
+func WithConstraintPolicy(policy ConstraintPolicy) Option {
+	return func(p *Parser) { p.options.Constraints = policy }
+}
+
+// WithoutCompletionTracking makes ParsePartial always report Complete.
+func WithoutCompletionTracking() Option {
+	return func(p *Parser) { p.options.Streaming.TrackCompletionState = false }
+}
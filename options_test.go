@@ -0,0 +1,97 @@
+package sap
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestParserWithReturnsCopy(t *testing.T) {
+	base := New()
+	strict := base.WithStrict(true)
+	if strict == base {
+		t.Fatal("WithStrict should return a new Parser")
+	}
+
+	input := `{name: "Alice", age: 30}`
+	userType := reflect.TypeOf(TestUser{})
+	if _, err := strict.Parse(input, userType); err == nil {
+		t.Error("expected strict parser to reject unquoted keys")
+	}
+	if _, err := base.Parse(input, userType); err != nil {
+		t.Errorf("base parser should be unchanged, got %v", err)
+	}
+
+	lenient := strict.With(func(p *Parser) { p.options.Strict = false })
+	if _, err := lenient.Parse(input, userType); err != nil {
+		t.Errorf("With should apply options to the copy, got %v", err)
+	}
+	if _, err := strict.Parse(input, userType); err == nil {
+		t.Error("With should leave the original strict")
+	}
+}
+
+func TestParseHelpersAcceptOptions(t *testing.T) {
+	input := `{name: "Alice", age: 30}`
+	if _, err := Parse[TestUser](input, WithStrict()); err == nil {
+		t.Error("expected WithStrict to reject unquoted keys")
+	}
+	if _, err := Parse[TestUser](input); err != nil {
+		t.Errorf("options must not leak into DefaultParser, got %v", err)
+	}
+}
+
+func TestWithMaxScore(t *testing.T) {
+	// The comma-separated list and "yes" each need a coercion
+	input := `{"title": "Dev", "experience": "go, rust", "active": "yes"}`
+
+	_, score, err := ParseWithScore[TestResume](input)
+	if err != nil {
+		t.Fatalf("ParseWithScore failed: %v", err)
+	}
+
+	_, err = Parse[TestResume](input, WithMaxScore(score.Total()-1))
+	if !errors.Is(err, ErrMaxScore) {
+		t.Errorf("expected ErrMaxScore, got %v", err)
+	}
+	if _, err := Parse[TestResume](input, WithMaxScore(score.Total())); err != nil {
+		t.Errorf("expected a score at the limit to pass, got %v", err)
+	}
+
+	// A later candidate within the limit still wins
+	input = `Draft: {"title": "Dev", "active": "yes"} Final: {"title": "Dev", "active": true}`
+	r, err := Parse[TestResume](input, WithMaxScore(1))
+	if err != nil || !r.Active {
+		t.Errorf("expected the clean candidate, got %+v, %v", r, err)
+	}
+}
+
+func TestParserConcurrentUse(t *testing.T) {
+	parser := New(WithRequiredPolicy(RequireNonOptional))
+	inputs := []string{
+		`{"name": "Alice", "age": 30, "email": "a@example.com"}`,
+		"```json\n{\"name\": \"Bob\", \"age\": \"41\", \"email\": \"b@example.com\"}\n```",
+		`{name: Carol, age: 52, email: c@example.com,}`,
+	}
+	userType := reflect.TypeOf(TestUser{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			input := inputs[i%len(inputs)]
+			if _, err := parser.Parse(input, userType); err != nil {
+				t.Errorf("shared parser: %v", err)
+			}
+			if _, err := Parse[TestUser](input); err != nil {
+				t.Errorf("DefaultParser: %v", err)
+			}
+			if _, _, err := ParsePartial[TestUser](input, WithMaxScore(100)); err != nil {
+				t.Errorf("ParsePartial with options: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,173 @@
+package sap
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// structPlans caches the compiled plan for each struct type.
+var structPlans sync.Map // reflect.Type -> *structPlan
+
+// structPlan is everything about a struct type that coerceToStruct would
+// otherwise work out on every call: its flattened fields, their tag
+// options, and tables for matching input keys to fields.
+//
+// A plan depends only on the type, so it is safe to share between
+// goroutines and parsers. Registries that can change at runtime, such as
+// custom coercers and enums, are still consulted on each coercion.
+type structPlan struct {
+	fields      []fieldPlan
+	hasEmbedded bool
+	validates   bool             // whether a pointer to the struct implements Validator
+	folded      map[string][]int // lowercased Go name -> indexes into fields
+}
+
+// fieldPlan is the compiled form of one struct field.
+type fieldPlan struct {
+	field    reflect.StructField
+	index    []int        // index path for nested embedded fields
+	goPath   string       // Go selector from the struct, e.g. "Contributor.Name"
+	opts     fieldOptions // parsed `gsap` and `json` tag options
+	jsonName string       // name from the json tag, or ""
+	pathName string       // name used in error paths, see fieldPathName
+	// absentStruct reports whether the field's own required fields are
+	// checked when it is absent
+	absentStruct bool
+	// direct reports whether generated code may convert a matching JSON
+	// value itself: the field has no tag options that change coercion and
+	// its type doesn't decode itself
+	direct bool
+	// coerce converts an input value for the field
+	coerce func(c *TypeCoercer, value interface{}, score *Score) (interface{}, error)
+}
+
+// structPlanFor returns the plan for struct type t, compiling and caching
+// it on first use.
+func structPlanFor(t reflect.Type) *structPlan {
+	if plan, ok := structPlans.Load(t); ok {
+		return plan.(*structPlan)
+	}
+	plan, _ := structPlans.LoadOrStore(t, compileStructPlan(t))
+	return plan.(*structPlan)
+}
+
+// compileStructPlan builds the plan for struct type t.
+func compileStructPlan(t reflect.Type) *structPlan {
+	fields := flattenStructFields(t)
+	plan := &structPlan{
+		fields:      make([]fieldPlan, 0, len(fields)),
+		hasEmbedded: len(fields) != t.NumField(),
+		validates:   reflect.PointerTo(t).Implements(validatorType),
+		folded:      make(map[string][]int, len(fields)),
+	}
+
+	for _, sf := range fields {
+		// Unexported fields can't be set
+		if !sf.field.IsExported() {
+			continue
+		}
+
+		opts := parseFieldOptions(sf.field.Tag)
+		fp := fieldPlan{
+			field:    sf.field,
+			index:    sf.index,
+			goPath:   goPath(t, sf.index),
+			opts:     opts,
+			pathName: fieldPathName(sf.field),
+		}
+		if tag, ok := sf.field.Tag.Lookup("json"); ok {
+			if name, _, _ := strings.Cut(tag, ","); name != "-" {
+				fp.jsonName = name
+			}
+		}
+
+		fieldType := sf.field.Type
+		fp.absentStruct = !opts.hasDefault && fieldType.Kind() == reflect.Struct &&
+			fieldType != timeType && !isUnmarshaler(fieldType)
+		fp.direct = !opts.affectsCoercion() && !isUnmarshaler(fieldType)
+
+		fp.coerce = func(c *TypeCoercer, value interface{}, score *Score) (interface{}, error) {
+			return c.coerceValue(value, fieldType, score)
+		}
+		if opts.affectsCoercion() {
+			fp.coerce = func(c *TypeCoercer, value interface{}, score *Score) (interface{}, error) {
+				if value == nil {
+					return c.coerceValue(value, fieldType, score)
+				}
+				return c.coerceWithOptions(value, fieldType, opts, score)
+			}
+		}
+
+		folded := strings.ToLower(sf.field.Name)
+		plan.folded[folded] = append(plan.folded[folded], len(plan.fields))
+		plan.fields = append(plan.fields, fp)
+	}
+	return plan
+}
+
+// describedType returns the type JSONSchema, RenderFormat and Grammar
+// describe for the field, and whether Parse requires it. A required field
+// may not be null, so a required pointer is described by its element.
+func (fp *fieldPlan) describedType() (t reflect.Type, required bool) {
+	t = fp.field.Type
+	required = fp.opts.required && !fp.opts.hasDefault
+	if required && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t, required
+}
+
+// goPath returns the Go selector for the field at index in struct type t.
+func goPath(t reflect.Type, index []int) string {
+	names := make([]string, len(index))
+	for i, idx := range index {
+		f := t.Field(idx)
+		names[i], t = f.Name, f.Type
+	}
+	return strings.Join(names, ".")
+}
+
+// keyMatcher finds the keys of an input object that hold the values for a
+// plan's fields.
+type keyMatcher struct {
+	plan   *structPlan
+	mapVal map[string]interface{}
+	folded []string // field index -> a key matching its Go name case-insensitively
+}
+
+// matchKeys returns a keyMatcher for mapVal.
+func (p *structPlan) matchKeys(mapVal map[string]interface{}) keyMatcher {
+	return keyMatcher{plan: p, mapVal: mapVal}
+}
+
+// find returns the key that holds the value for field i: the JSON tag name,
+// then the field name, then a case-insensitive match of the field name,
+// which is reported as fuzzy. It returns "" if no key matches.
+func (m *keyMatcher) find(i int) (key string, fuzzy bool) {
+	fp := &m.plan.fields[i]
+	if fp.jsonName != "" {
+		if _, ok := m.mapVal[fp.jsonName]; ok {
+			return fp.jsonName, false
+		}
+	}
+	if _, ok := m.mapVal[fp.field.Name]; ok {
+		return fp.field.Name, false
+	}
+
+	// Index the keys by folded name the first time an exact match fails,
+	// so fuzzy matching costs one pass over the keys rather than one per
+	// field
+	if m.folded == nil {
+		m.folded = make([]string, len(m.plan.fields))
+		for k := range m.mapVal {
+			for _, j := range m.plan.folded[strings.ToLower(k)] {
+				m.folded[j] = k
+			}
+		}
+	}
+	if k := m.folded[i]; k != "" {
+		return k, true
+	}
+	return "", false
+}
@@ -0,0 +1,72 @@
+package sap
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestStructPlanCached(t *testing.T) {
+	userType := reflect.TypeOf(TestUser{})
+
+	plans := make([]*structPlan, 8)
+	var wg sync.WaitGroup
+	for i := range plans {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			plans[i] = structPlanFor(userType)
+		}(i)
+	}
+	wg.Wait()
+
+	for _, p := range plans[1:] {
+		if p != plans[0] {
+			t.Fatal("expected every caller to get the same plan")
+		}
+	}
+	if len(plans[0].fields) != userType.NumField() {
+		t.Errorf("expected %d fields, got %d", userType.NumField(), len(plans[0].fields))
+	}
+}
+
+type planKeysTarget struct {
+	Name   string `json:"full_name"`
+	Email  string
+	Region string `json:"-"`
+	planInner
+}
+
+type planInner struct {
+	ID string
+}
+
+func TestStructPlanKeyMatching(t *testing.T) {
+	plan := structPlanFor(reflect.TypeOf(planKeysTarget{}))
+
+	tests := []struct {
+		name      string
+		input     map[string]interface{}
+		field     int
+		wantKey   string
+		wantFuzzy bool
+	}{
+		{"json name", map[string]interface{}{"full_name": "a", "Name": "b"}, 0, "full_name", false},
+		{"go name", map[string]interface{}{"Name": "b"}, 0, "Name", false},
+		{"case-insensitive", map[string]interface{}{"EMAIL": "x"}, 1, "EMAIL", true},
+		{"exact beats fuzzy", map[string]interface{}{"email": "x", "Email": "y"}, 1, "Email", false},
+		{"json skip still matches go name", map[string]interface{}{"region": "eu"}, 2, "region", true},
+		{"embedded field", map[string]interface{}{"id": "1"}, 3, "id", true},
+		{"no match", map[string]interface{}{"other": 1}, 1, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys := plan.matchKeys(tt.input)
+			key, fuzzy := keys.find(tt.field)
+			if key != tt.wantKey || fuzzy != tt.wantFuzzy {
+				t.Errorf("got (%q, %v), want (%q, %v)", key, fuzzy, tt.wantKey, tt.wantFuzzy)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package sap
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// boolWords are the answers recognized when a bool is extracted from prose.
+// Shorter variants like "y" or "on" are left out because they are common
+// words in ordinary sentences.
+var boolWords = []enumCandidate{
+	{text: "true", member: 1},
+	{text: "yes", member: 1},
+	{text: "false", member: 0},
+	{text: "no", member: 0},
+}
+
+// coerceFromText extracts an enum or bool value from a free-form answer that
+// contains no JSON, such as "The sentiment here is clearly NEGATIVE". It
+// counts whole-word occurrences of each allowed value and its aliases,
+// skipping negated ones like "not positive", and picks the value mentioned
+// most often. The boolean result reports whether
+// targetType supports extraction from text at all.
+func (c *TypeCoercer) coerceFromText(text string, targetType reflect.Type, score *Score) (interface{}, bool, error) {
+	if targetType.Kind() == reflect.Ptr {
+		elem, ok, err := c.coerceFromText(text, targetType.Elem(), score)
+		if !ok || err != nil {
+			return nil, ok, err
+		}
+		result := reflect.New(targetType.Elem())
+		result.Elem().Set(reflect.ValueOf(elem))
+		return result.Interface(), true, nil
+	}
+
+	var def *enumDef
+	switch targetType.Kind() {
+	case reflect.Bool:
+		def = &enumDef{
+			names:  []string{"false", "true"},
+			values: []reflect.Value{reflect.ValueOf(false), reflect.ValueOf(true)},
+		}
+		i, err := countEnumMentions(text, def, boolWords)
+		if err != nil {
+			return nil, true, err
+		}
+		score.AddFlag(FlagExtractedFromText, 2)
+		return reflect.ValueOf(i == 1).Convert(targetType).Interface(), true, nil
+	case reflect.String, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		def = lookupEnum(targetType)
+	}
+	if def == nil {
+		return nil, false, nil
+	}
+
+	i, err := countEnumMentions(text, def, def.candidates())
+	if err != nil {
+		return nil, true, err
+	}
+	score.AddFlag(FlagExtractedFromText, 2)
+	return def.values[i].Convert(targetType).Interface(), true, nil
+}
+
+// countEnumMentions returns the index of the enum value mentioned most often
+// in text. It fails if no value is mentioned, or if several values tie for
+// the most mentions.
+func countEnumMentions(text string, def *enumDef, candidates []enumCandidate) (int, error) {
+	counts := make([]int, len(def.names))
+	for _, c := range candidates {
+		counts[c.member] += countMentions(text, c.text)
+	}
+
+	best := 0
+	for _, n := range counts {
+		if n > best {
+			best = n
+		}
+	}
+	if best == 0 {
+		return 0, fmt.Errorf("%w and no allowed value mentioned (want one of %s)",
+			ErrNoJSON, strings.Join(def.names, ", "))
+	}
+
+	var tied []string
+	winner := 0
+	for i, n := range counts {
+		if n == best {
+			tied = append(tied, def.names[i])
+			winner = i
+		}
+	}
+	if len(tied) > 1 {
+		return 0, fmt.Errorf("%w answer: %s are each mentioned %d times",
+			ErrAmbiguous, strings.Join(tied, ", "), best)
+	}
+	return winner, nil
+}
+
+// negationWindow is how many words before a mention are checked for a
+// negation, so that "not positive" and "isn't really positive" don't count
+// as mentions of positive.
+const negationWindow = 3
+
+// reClauseBreak splits prose into clauses; a negation doesn't reach past
+// one, as in "Not negative. Positive."
+var reClauseBreak = regexp.MustCompile(`[.,;:!?\n]+`)
+
+// countMentions counts the whole-word occurrences of phrase in text, like
+// countWordOccurrences, leaving out those negated within their clause.
+func countMentions(text, phrase string) int {
+	phraseWords := splitWords(phrase)
+	if len(phraseWords) == 0 {
+		return 0
+	}
+	joined := strings.Join(phraseWords, " ")
+
+	count := 0
+	for _, clause := range reClauseBreak.Split(text, -1) {
+		words := splitWords(clause)
+		for i := 0; i+len(phraseWords) <= len(words); i++ {
+			if strings.Join(words[i:i+len(phraseWords)], " ") != joined {
+				continue
+			}
+			window := words[max(0, i-negationWindow) : i+len(phraseWords)]
+			if !negates(strings.Join(window, " "), phrase) {
+				count++
+			}
+		}
+	}
+	return count
+}
@@ -0,0 +1,154 @@
+package sap
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseEnumFromProse(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  testSentiment
+	}{
+		{"uppercase mention", "The sentiment here is clearly NEGATIVE, because the customer asked for a refund.", "negative"},
+		{"most mentioned wins", "Positive overall. The tone is positive even though one line reads negative.", "positive"},
+		{"bare word", "neutral", "neutral"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, score, err := ParseWithScore[testSentiment](tt.input)
+			if err != nil {
+				t.Fatalf("ParseWithScore failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if _, ok := score.Flags()[FlagExtractedFromText]; !ok {
+				t.Errorf("expected %s flag, got %v", FlagExtractedFromText, score.Flags())
+			}
+		})
+	}
+}
+
+func TestParseEnumFromProseNegation(t *testing.T) {
+	tests := []struct {
+		input string
+		want  testSentiment // "" for an error
+	}{
+		{"The sentiment is not positive.", ""},
+		{"The sentiment isn't really positive, it's negative.", "negative"},
+		{"Not negative. Positive.", "positive"},
+		{"Positive, not negative.", "positive"},
+		{"It's never neutral, and the tone is clearly negative.", "negative"},
+	}
+	for _, tt := range tests {
+		got, err := Parse[testSentiment](tt.input)
+		switch {
+		case tt.want == "" && err == nil:
+			t.Errorf("%q: expected an error, got %q", tt.input, got)
+		case tt.want != "" && (err != nil || got != tt.want):
+			t.Errorf("%q: got %q, %v; want %q", tt.input, got, err, tt.want)
+		}
+	}
+
+	if got, err := Parse[bool]("That is not true."); err == nil {
+		t.Errorf("expected an error for a negated bool, got %v", got)
+	}
+}
+
+func TestParseEnumFromProseAliases(t *testing.T) {
+	got, err := Parse[testMood]("Honestly this is a thumbs up from me.")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if got != "positive" {
+		t.Errorf("got %q, want positive", got)
+	}
+}
+
+func TestParseIntEnumFromProse(t *testing.T) {
+	got, err := Parse[*testPriority]("I'd file this as high.")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if got == nil || *got != testPriorityHigh {
+		t.Errorf("got %v, want High", got)
+	}
+}
+
+func TestParseEnumFromProseAmbiguous(t *testing.T) {
+	_, err := Parse[testSentiment]("It could be positive or negative, hard to say.")
+	if err == nil {
+		t.Fatal("expected ambiguity error")
+	}
+	msg := err.Error()
+	if !strings.Contains(msg, "ambiguous") || !strings.Contains(msg, "positive") || !strings.Contains(msg, "negative") {
+		t.Errorf("error should list tied values, got: %v", err)
+	}
+}
+
+func TestParseEnumFromProseNoMention(t *testing.T) {
+	if _, err := Parse[testSentiment]("I cannot classify this text."); err == nil {
+		t.Fatal("expected error when no value is mentioned")
+	}
+}
+
+func TestParseBoolFromProse(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{"Yes, the email is spam.", true},
+		{"No. This looks legitimate to me.", false},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse[bool](tt.input)
+		if err != nil {
+			t.Fatalf("Parse(%q) failed: %v", tt.input, err)
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+
+	if _, err := Parse[bool]("Yes and no."); err == nil {
+		t.Error("expected ambiguity error for tied yes/no")
+	}
+}
+
+func TestParseProseNotUsedForStructs(t *testing.T) {
+	_, err := Parse[TestUser]("The user is positive about it.")
+	if err == nil || !strings.Contains(err.Error(), "no JSON found") {
+		t.Errorf("expected no JSON error for struct target, got %v", err)
+	}
+}
+
+func TestParseProseStrictMode(t *testing.T) {
+	parser := NewParser().WithStrict(true)
+	if _, _, err := parser.ParseWithScore("clearly negative", reflect.TypeOf(testSentiment(""))); err == nil {
+		t.Error("strict mode should not extract enums from prose")
+	}
+}
+
+func TestCountWordOccurrences(t *testing.T) {
+	tests := []struct {
+		text, phrase string
+		want         int
+	}{
+		{"Negative, NEGATIVE and negatively", "negative", 2},
+		{"it is in progress now", "in_progress", 1},
+		{"thumbs-up from me", "thumbs up", 1},
+		{"positively", "positive", 0},
+		{"", "positive", 0},
+	}
+
+	for _, tt := range tests {
+		if got := countWordOccurrences(tt.text, tt.phrase); got != tt.want {
+			t.Errorf("countWordOccurrences(%q, %q) = %d, want %d", tt.text, tt.phrase, got, tt.want)
+		}
+	}
+}
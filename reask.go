@@ -0,0 +1,214 @@
+package sap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Message is one message of a conversation with a language model. Role is
+// "system", "user" or "assistant".
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Model sends a conversation to a language model and returns its reply.
+// Wrap any client in one to use it with Reask.
+type Model func(ctx context.Context, messages []Message) (string, error)
+
+// Attempt records one reply from the model and how it parsed.
+type Attempt struct {
+	Response string
+	Score    *Score // nil if nothing could be parsed from the response
+	Err      error  // why the response was not accepted; nil if it was
+	Feedback string // the corrective message sent in reply; "" if none
+}
+
+// ReaskOption configures Reask.
+type ReaskOption func(*reaskConfig)
+
+type reaskConfig struct {
+	retries  int
+	maxScore int // -1 for no limit
+	parse    []Option
+}
+
+// ReaskRetries sets how many times Reask asks again after the first
+// reply; the default is 2.
+func ReaskRetries(n int) ReaskOption {
+	return func(c *reaskConfig) { c.retries = n }
+}
+
+// ReaskMaxScore makes Reask ask again when a reply parses but needed
+// coercions scoring more than n, such as units stripped from numbers or
+// fuzzy field names. By default only replies that fail to parse are
+// retried.
+func ReaskMaxScore(n int) ReaskOption {
+	return func(c *reaskConfig) { c.maxScore = n }
+}
+
+// ReaskParseOptions sets the options replies are parsed with.
+func ReaskParseOptions(opts ...Option) ReaskOption {
+	return func(c *reaskConfig) { c.parse = append(c.parse, opts...) }
+}
+
+// Reask asks model for a T and parses the reply, asking again when it
+// fails to parse. Each retry adds the reply and a corrective message,
+// listing the fields that failed and the required fields that were
+// missing, to the conversation:
+//
+//	order, attempts, err := sap.Reask[Order](ctx, model, []sap.Message{
+//		{Role: "user", Content: "Extract the order from this email:\n" + email},
+//	}, sap.ReaskRetries(3))
+//
+// It stops at the first reply that parses, within the ReaskMaxScore limit
+// if one is set, and returns it. Otherwise it returns the best reply (the
+// one with the fewest failed fields, then the lowest score) with the error
+// that reply had. Either way, attempts holds every reply in order. An
+// error from model or ctx ends the loop at once and is returned as is,
+// along with the best value so far.
+func Reask[T any](ctx context.Context, model Model, messages []Message, opts ...ReaskOption) (T, []Attempt, error) {
+	var zero T
+	config := reaskConfig{retries: 2, maxScore: -1}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	parser := parserFor(config.parse)
+	targetType := reflect.TypeOf((*T)(nil)).Elem()
+
+	conversation := append([]Message(nil), messages...)
+	var attempts []Attempt
+	var best *ParseResult
+	var bestErr error
+	for i := 0; i <= config.retries; i++ {
+		if err := ctx.Err(); err != nil {
+			return reaskValue[T](best), attempts, err
+		}
+		response, err := model(ctx, conversation)
+		if err != nil {
+			return reaskValue[T](best), attempts, err
+		}
+
+		res, err := parser.parse(response, targetType, false)
+		if err == nil && config.maxScore >= 0 && res.Score.Total() > config.maxScore {
+			err = maxScoreError(res.Score, config.maxScore)
+		}
+		attempt := Attempt{Response: response, Err: err}
+		if res != nil {
+			attempt.Score = res.Score
+		}
+		if err == nil {
+			attempts = append(attempts, attempt)
+			value, _ := res.Value.(T)
+			return value, attempts, nil
+		}
+
+		if res != nil && (best == nil || betterReply(res, err, best, bestErr)) {
+			best, bestErr = res, err
+		} else if best == nil {
+			bestErr = err
+		}
+		if i < config.retries {
+			attempt.Feedback = reaskFeedback(res, err)
+			conversation = append(conversation,
+				Message{Role: "assistant", Content: response},
+				Message{Role: "user", Content: attempt.Feedback})
+		}
+		attempts = append(attempts, attempt)
+	}
+
+	if best == nil {
+		return zero, attempts, fmt.Errorf("no usable reply after %d attempts: %w", len(attempts), bestErr)
+	}
+	return reaskValue[T](best), attempts, fmt.Errorf("no acceptable reply after %d attempts: %w", len(attempts), bestErr)
+}
+
+// reaskValue returns the value of res, or the zero T if there is none.
+func reaskValue[T any](res *ParseResult) T {
+	var value T
+	if res != nil {
+		value, _ = res.Value.(T)
+	}
+	return value
+}
+
+// betterReply reports whether a reply that parsed into res with err beats
+// the best so far, by the same measure Parse uses to choose between
+// candidates.
+func betterReply(res *ParseResult, err error, best *ParseResult, bestErr error) bool {
+	var perr, bestPerr *ParseError
+	errors.As(err, &perr)
+	errors.As(bestErr, &bestPerr)
+	return betterCandidate(perr, res.Score, matchedFields(res.Presence), bestPerr, best.Score, matchedFields(best.Presence))
+}
+
+// flagHints tell the model how to avoid the coercions behind score flags.
+// Flags for coercions that are the normal way to write a value, like times
+// from strings, have none.
+var flagHints = map[string]string{
+	FlagFloatToInt:           "Write whole numbers for integer fields.",
+	FlagStringToInt:          "Write numbers as JSON numbers, not strings.",
+	FlagStringToFloat:        "Write numbers as JSON numbers, not strings.",
+	FlagBoolToInt:            "Write numbers as JSON numbers, not booleans.",
+	FlagStringToBool:         "Write booleans as true or false.",
+	FlagNumberToBool:         "Write booleans as true or false.",
+	FlagFuzzyFieldMatch:      "Use the field names exactly as given.",
+	FlagEnumCaseInsensitive:  "Use one of the allowed values exactly as given.",
+	FlagEnumFuzzyMatch:       "Use one of the allowed values exactly as given.",
+	FlagEnumWordMatch:        "Use one of the allowed values exactly as given.",
+	FlagEnumAliasMatch:       "Use one of the allowed values exactly as given.",
+	FlagEnumDescriptionMatch: "Use one of the allowed values exactly as given.",
+	FlagMarkdownStripped:     "Don't format values with markdown.",
+	FlagUnitStripped:         "Write numbers without units, currency symbols or thousands separators.",
+	FlagMultiplierApplied:    "Write numbers in full, without suffixes like K or M.",
+	FlagNullStringCoerced:    `Write null for missing values, not strings like "N/A".`,
+	FlagCommaSplitToSlice:    "Write lists as JSON arrays.",
+	FlagExtractedFromText:    "Reply with JSON.",
+}
+
+// reaskFeedback returns the corrective message for a reply that parsed
+// into res, if anything, and failed with err.
+func reaskFeedback(res *ParseResult, err error) string {
+	var lines []string
+	var perr *ParseError
+	switch {
+	case errors.As(err, &perr):
+		for _, fe := range perr.Errors {
+			path := fe.Path
+			if path == "" {
+				path = "the response"
+			}
+			if errors.Is(fe.Cause, ErrMissingField) {
+				lines = append(lines, fmt.Sprintf("- %s: required, but missing", path))
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("- %s: %v", path, fe.Cause))
+		}
+	case errors.Is(err, ErrNoJSON):
+		lines = append(lines, "- It contained no JSON.")
+	case errors.Is(err, ErrMaxScore) && res != nil:
+		flags := make([]string, 0, len(res.Score.Flags()))
+		for flag := range res.Score.Flags() {
+			flags = append(flags, flag)
+		}
+		sort.Strings(flags)
+		seen := make(map[string]bool)
+		for _, flag := range flags {
+			if hint, ok := flagHints[flag]; ok && !seen[hint] {
+				seen[hint] = true
+				lines = append(lines, "- "+hint)
+			}
+		}
+		if len(lines) == 0 {
+			lines = append(lines, "- Follow the requested format exactly.")
+		}
+	default:
+		lines = append(lines, "- "+err.Error())
+	}
+	return "Your previous response could not be used:\n" + strings.Join(lines, "\n") +
+		"\n\nReply again with the complete, corrected JSON and nothing else."
+}
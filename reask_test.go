@@ -0,0 +1,192 @@
+package sap
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type testReaskOrder struct {
+	ID       string  `json:"id" gsap:"required"`
+	Status   string  `json:"status" gsap:"enum=open|shipped"`
+	Quantity int     `json:"quantity" gsap:"min=1"`
+	Total    float64 `json:"total"`
+}
+
+// fakeModel replies with responses in turn and records each conversation
+// it was sent.
+type fakeModel struct {
+	responses []string
+	calls     [][]Message
+}
+
+func (m *fakeModel) complete(ctx context.Context, messages []Message) (string, error) {
+	m.calls = append(m.calls, append([]Message(nil), messages...))
+	if len(m.calls) > len(m.responses) {
+		return "", errors.New("fake model: out of responses")
+	}
+	return m.responses[len(m.calls)-1], nil
+}
+
+var testReaskPrompt = []Message{{Role: "user", Content: "Extract the order."}}
+
+func TestReask(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses []string
+		opts      []ReaskOption
+		want      testReaskOrder
+		attempts  int
+		feedback  []string // in the first corrective message
+	}{
+		{
+			name:      "first reply parses",
+			responses: []string{`{"id": "A1", "status": "open", "quantity": 2, "total": 9.5}`},
+			want:      testReaskOrder{ID: "A1", Status: "open", Quantity: 2, Total: 9.5},
+			attempts:  1,
+		},
+		{
+			name: "field errors",
+			responses: []string{
+				`{"status": "xyzzy", "quantity": 0, "total": 9.5}`,
+				`{"id": "A1", "status": "open", "quantity": 2, "total": 9.5}`,
+			},
+			want:     testReaskOrder{ID: "A1", Status: "open", Quantity: 2, Total: 9.5},
+			attempts: 2,
+			feedback: []string{"- id: required, but missing", "- status: ", "open, shipped", "- quantity: ", "less than min 1"},
+		},
+		{
+			name: "no JSON",
+			responses: []string{
+				`I couldn't find an order in that email.`,
+				`{"id": "A1", "status": "open", "quantity": 2, "total": 9.5}`,
+			},
+			want:     testReaskOrder{ID: "A1", Status: "open", Quantity: 2, Total: 9.5},
+			attempts: 2,
+			feedback: []string{"- It contained no JSON."},
+		},
+		{
+			name: "score above limit",
+			responses: []string{
+				`{"id": "A1", "status": "Open", "quantity": "2 boxes", "total": "$9.50"}`,
+				`{"id": "A1", "status": "open", "quantity": 2, "total": 9.5}`,
+			},
+			opts:     []ReaskOption{ReaskMaxScore(0)},
+			want:     testReaskOrder{ID: "A1", Status: "open", Quantity: 2, Total: 9.5},
+			attempts: 2,
+			feedback: []string{"- Use one of the allowed values exactly as given.", "- Write numbers as JSON numbers, not strings.", "- Write numbers without units"},
+		},
+		{
+			name:      "score within limit",
+			responses: []string{`{"id": "A1", "status": "Open", "quantity": 2, "total": 9.5}`},
+			opts:      []ReaskOption{ReaskMaxScore(5)},
+			want:      testReaskOrder{ID: "A1", Status: "open", Quantity: 2, Total: 9.5},
+			attempts:  1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := &fakeModel{responses: tt.responses}
+			got, attempts, err := Reask[testReaskOrder](context.Background(), model.complete, testReaskPrompt, tt.opts...)
+			if err != nil {
+				t.Fatalf("Reask failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+			if len(attempts) != tt.attempts {
+				t.Fatalf("got %d attempts, want %d", len(attempts), tt.attempts)
+			}
+			last := attempts[len(attempts)-1]
+			if last.Err != nil || last.Feedback != "" || last.Score == nil {
+				t.Errorf("unexpected last attempt: %+v", last)
+			}
+			if tt.attempts == 1 {
+				return
+			}
+
+			first := attempts[0]
+			if first.Err == nil || first.Response != tt.responses[0] {
+				t.Errorf("unexpected first attempt: %+v", first)
+			}
+			for _, want := range tt.feedback {
+				if !strings.Contains(first.Feedback, want) {
+					t.Errorf("feedback lacks %q:\n%s", want, first.Feedback)
+				}
+			}
+			wantCall := append(append([]Message(nil), testReaskPrompt...),
+				Message{Role: "assistant", Content: tt.responses[0]},
+				Message{Role: "user", Content: first.Feedback})
+			if !equalMessages(model.calls[1], wantCall) {
+				t.Errorf("second call got %+v, want %+v", model.calls[1], wantCall)
+			}
+		})
+	}
+}
+
+func TestReaskExhausted(t *testing.T) {
+	model := &fakeModel{responses: []string{
+		`{"status": "xyzzy", "quantity": 0}`,
+		`{"id": "A1", "status": "xyzzy", "quantity": 3}`,
+		`no idea`,
+	}}
+	got, attempts, err := Reask[testReaskOrder](context.Background(), model.complete, testReaskPrompt)
+	var perr *ParseError
+	if !errors.As(err, &perr) || !strings.Contains(err.Error(), "after 3 attempts") {
+		t.Fatalf("expected *ParseError after 3 attempts, got %v", err)
+	}
+	if len(perr.Errors) != 1 || perr.Errors[0].Path != "status" {
+		t.Errorf("expected the best reply's error, got %v", err)
+	}
+	if got != (testReaskOrder{ID: "A1", Quantity: 3}) {
+		t.Errorf("expected the best reply, got %+v", got)
+	}
+	if len(attempts) != 3 || attempts[2].Score != nil || attempts[2].Feedback != "" {
+		t.Errorf("unexpected attempts: %+v", attempts)
+	}
+	for i, a := range attempts[:2] {
+		if a.Score == nil || a.Err == nil || a.Feedback == "" {
+			t.Errorf("attempt %d: unexpected %+v", i, a)
+		}
+	}
+	if len(testReaskPrompt) != 1 {
+		t.Errorf("Reask changed the caller's messages: %+v", testReaskPrompt)
+	}
+
+	model = &fakeModel{responses: []string{`nothing`, `still nothing`}}
+	_, attempts, err = Reask[testReaskOrder](context.Background(), model.complete, testReaskPrompt, ReaskRetries(1))
+	if !errors.Is(err, ErrNoJSON) || len(attempts) != 2 {
+		t.Errorf("expected ErrNoJSON after 2 attempts, got %v, %d attempts", err, len(attempts))
+	}
+}
+
+func TestReaskStops(t *testing.T) {
+	model := &fakeModel{responses: []string{`{"id": "A1", "status": "xyzzy"}`}}
+	got, attempts, err := Reask[testReaskOrder](context.Background(), model.complete, testReaskPrompt)
+	if err == nil || !strings.Contains(err.Error(), "out of responses") {
+		t.Fatalf("expected the model's error, got %v", err)
+	}
+	if got.ID != "A1" || len(attempts) != 1 {
+		t.Errorf("expected the first reply's value, got %+v, %d attempts", got, len(attempts))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	model = &fakeModel{responses: []string{`{"id": "A1"}`}}
+	if _, _, err := Reask[testReaskOrder](ctx, model.complete, testReaskPrompt); !errors.Is(err, context.Canceled) || len(model.calls) != 0 {
+		t.Errorf("expected context.Canceled before calling the model, got %v", err)
+	}
+}
+
+func equalMessages(a, b []Message) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
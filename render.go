@@ -0,0 +1,371 @@
+package sap
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// formatNames describes format= options in rendered formats.
+var formatNames = map[string]string{
+	"email":     "email address",
+	"uuid":      "UUID",
+	"url":       "URL",
+	"date":      "date as YYYY-MM-DD",
+	"date-time": "RFC 3339 date-time",
+}
+
+// RenderFormat returns a compact, TypeScript-like description of the JSON
+// that Parse[T] expects, for the output format section of a prompt:
+//
+//	format, err := sap.RenderFormat[Order]()
+//	prompt := "Answer in JSON using this schema:\n" + format
+//
+// For a struct it renders something like
+//
+//	{
+//	  // Order number
+//	  id: string,
+//	  status: "open" | "closed",
+//	  currency?: string, // default: USD
+//	  items: {
+//	    sku: string,
+//	    quantity: int, // between 1 and 99
+//	  }[],
+//	  notes?: string | null,
+//	}
+//
+// Fields are named as Parse matches them, enum and union types list their
+// values, and `description` tags and enum descriptions become comments.
+// Fields marked "?" may be left out; required fields, pointers included,
+// are never marked or null. Recursive types are defined by name before the
+// main format.
+//
+// Types with no JSON form, such as channels and funcs, and fields with
+// malformed `gsap` tags can't be rendered; the error names the field.
+func RenderFormat[T any]() (string, error) {
+	return renderFormat(reflect.TypeOf((*T)(nil)).Elem())
+}
+
+// renderFormat renders the format of t.
+func renderFormat(t reflect.Type) (string, error) {
+	r := &formatRenderer{recursive: make(map[reflect.Type]bool), stack: make(map[reflect.Type]bool)}
+
+	// The first pass finds the recursive types, which the second renders
+	// by name
+	out, err := r.render(t, fieldOptions{}, "", "")
+	if err != nil || len(r.order) == 0 {
+		return out, err
+	}
+
+	var b strings.Builder
+	for _, rt := range r.order {
+		body, err := r.object(rt, "", "", "", "")
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "%s %s\n\n", rt.Name(), body)
+	}
+	out, err = r.render(t, fieldOptions{}, "", "")
+	if err != nil {
+		return "", err
+	}
+	b.WriteString(out)
+	return b.String(), nil
+}
+
+// formatRenderer renders types in the style of RenderFormat.
+type formatRenderer struct {
+	recursive map[reflect.Type]bool // struct types rendered by name
+	order     []reflect.Type        // recursive types, in the order found
+	stack     map[reflect.Type]bool // struct types being rendered
+}
+
+// render describes values of type t in a field with the given options.
+// Lines after the first are prefixed with indent.
+func (r *formatRenderer) render(t reflect.Type, opts fieldOptions, indent, path string) (string, error) {
+	shape, err := shapeOf(t, opts)
+	if err != nil {
+		return "", describeError("render format", path, err)
+	}
+
+	switch shape.kind {
+	case shapeTime, shapeText, shapeString:
+		return "string", nil
+	case shapeRaw, shapeAny:
+		return "any", nil
+	case shapePointer:
+		elem, err := r.render(t.Elem(), opts, indent, path)
+		if err != nil {
+			return "", err
+		}
+		if strings.HasSuffix(elem, " | null") {
+			return elem, nil
+		}
+		return elem + " | null", nil
+	case shapeUnion:
+		return r.union(shape.union, indent, path)
+	case shapeEnum:
+		return enumLiterals(shape.enum), nil
+	case shapeInt, shapeUint:
+		return "int", nil
+	case shapeFloat:
+		return "float", nil
+	case shapeBool:
+		return "bool", nil
+	case shapeList:
+		elem, err := r.render(t.Elem(), opts, indent, path+"[]")
+		if err != nil {
+			return "", err
+		}
+		if isUnionText(elem) {
+			elem = "(" + elem + ")"
+		}
+		return elem + "[]", nil
+	case shapeMap:
+		elem, err := r.render(t.Elem(), fieldOptions{}, indent, path+"{}")
+		if err != nil {
+			return "", err
+		}
+		return "map<string, " + elem + ">", nil
+	default: // shapeStruct
+		if r.recursive[t] {
+			return t.Name(), nil
+		}
+		if r.stack[t] {
+			r.recursive[t] = true
+			r.order = append(r.order, t)
+			return t.Name(), nil
+		}
+		r.stack[t] = true
+		defer delete(r.stack, t)
+		return r.object(t, indent, path, "", "")
+	}
+}
+
+// object renders the fields of struct type t, one per line. For a variant
+// of a discriminated union, key is the discriminator and tag its value,
+// which comes first.
+func (r *formatRenderer) object(t reflect.Type, indent, path string, key, tag string) (string, error) {
+	inner := indent + "  "
+	var b strings.Builder
+	b.WriteString("{\n")
+	if key != "" {
+		fmt.Fprintf(&b, "%s%s: %s,\n", inner, renderKey(key), strconv.Quote(tag))
+	}
+
+	plan := structPlanFor(t)
+	for i := range plan.fields {
+		fp := &plan.fields[i]
+		name := fp.pathName
+		if fp.opts.skip || (key != "" && name == key) {
+			continue
+		}
+		fieldType, required := fp.describedType()
+		typ, err := r.render(fieldType, fp.opts, inner, joinSchemaPath(path, name))
+		if err != nil {
+			return "", err
+		}
+
+		for _, line := range fieldComments(fp) {
+			b.WriteString(inner + "// " + line + "\n")
+		}
+		optional := ""
+		if !required && (fieldType.Kind() == reflect.Ptr || fp.opts.omitempty || fp.opts.hasDefault) {
+			optional = "?"
+		}
+		fmt.Fprintf(&b, "%s%s%s: %s,", inner, renderKey(name), optional, typ)
+		if hints := fieldHints(fp.field.Type, fp.opts); len(hints) > 0 {
+			b.WriteString(" // " + strings.Join(hints, ", "))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString(indent + "}")
+	return b.String(), nil
+}
+
+// union renders the variants of a union separated by "|". The variants of
+// a discriminated union start with their discriminator value.
+func (r *formatRenderer) union(def *unionDef, indent, path string) (string, error) {
+	var tags []string
+	if def.discriminator != "" {
+		tags = def.tagNames()
+	}
+
+	variants := make([]string, len(def.variants))
+	for i, variant := range def.variants {
+		for variant.Kind() == reflect.Ptr {
+			variant = variant.Elem()
+		}
+		var s string
+		var err error
+		if tags != nil && variant.Kind() == reflect.Struct && !r.recursive[variant] && !r.stack[variant] {
+			r.stack[variant] = true
+			s, err = r.object(variant, indent, path, def.discriminator, tags[i])
+			delete(r.stack, variant)
+		} else {
+			s, err = r.render(variant, fieldOptions{}, indent, path)
+		}
+		if err != nil {
+			return "", err
+		}
+		variants[i] = s
+	}
+	return strings.Join(variants, " | "), nil
+}
+
+// enumLiterals renders the names of an enum as string literals.
+func enumLiterals(def *enumDef) string {
+	literals := make([]string, len(def.names))
+	for i, name := range def.names {
+		literals[i] = strconv.Quote(name)
+	}
+	return strings.Join(literals, " | ")
+}
+
+// fieldComments returns the comment lines above a field: its description
+// and the descriptions of its enum values.
+func fieldComments(fp *fieldPlan) []string {
+	var lines []string
+	if fp.opts.description != "" {
+		lines = append(lines, strings.Split(fp.opts.description, "\n")...)
+	}
+
+	t := fp.field.Type
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Interface || t.Kind() == reflect.Struct || t.Kind() == reflect.Map {
+		return lines
+	}
+	if def := fp.opts.enumDef(t); def != nil {
+		for i, name := range def.names {
+			if d := def.description(i); d != "" {
+				lines = append(lines, fmt.Sprintf("%q: %s", name, d))
+			}
+		}
+	}
+	return lines
+}
+
+// fieldHints returns short notes on the values a field accepts, from its
+// type and tag options, such as "between 1 and 99".
+func fieldHints(t reflect.Type, opts fieldOptions) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var hints []string
+	each := ""
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		unit := "items"
+		if t.Kind() == reflect.Map {
+			unit = "entries"
+		}
+		if t.Kind() == reflect.Array {
+			hints = append(hints, fmt.Sprintf("exactly %d %s", t.Len(), unit))
+		} else if r := rangeText(intFloat(opts.minLen), intFloat(opts.maxLen)); r != "" {
+			hints = append(hints, r+" "+unit)
+		}
+		if t.Kind() == reflect.Map {
+			return hints
+		}
+		t, each = t.Elem(), "each "
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+	case reflect.String:
+		if r := rangeText(intFloat(opts.minLen), intFloat(opts.maxLen)); r != "" {
+			hints = append(hints, r+" characters")
+		}
+	}
+
+	switch {
+	case t == timeType:
+		hints = append(hints, each+formatNames["date-time"])
+	case t.Kind() == reflect.String:
+		if opts.format != "" {
+			hints = append(hints, each+formatNames[opts.format])
+		}
+		if opts.pattern != "" {
+			hints = append(hints, each+"matching "+opts.pattern)
+		}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Float64:
+		if r := rangeText(opts.min, opts.max); r != "" {
+			hints = append(hints, each+r)
+		}
+	}
+
+	if opts.hasDefault {
+		hints = append(hints, "default: "+opts.defaultValue)
+	}
+	return hints
+}
+
+// rangeText describes the bounds lo and hi, either of which may be nil.
+func rangeText(lo, hi *float64) string {
+	switch {
+	case lo != nil && hi != nil && *lo == *hi:
+		return "exactly " + formatFloat(*lo)
+	case lo != nil && hi != nil:
+		return "between " + formatFloat(*lo) + " and " + formatFloat(*hi)
+	case lo != nil:
+		return "at least " + formatFloat(*lo)
+	case hi != nil:
+		return "at most " + formatFloat(*hi)
+	}
+	return ""
+}
+
+// intFloat converts an optional length bound for rangeText.
+func intFloat(n *int) *float64 {
+	if n == nil {
+		return nil
+	}
+	f := float64(*n)
+	return &f
+}
+
+// renderKey renders a property name, quoting it unless it is a plain
+// identifier.
+func renderKey(name string) string {
+	if name == "" {
+		return `""`
+	}
+	for i, r := range name {
+		if r == '_' || unicode.IsLetter(r) || (i > 0 && unicode.IsDigit(r)) {
+			continue
+		}
+		return strconv.Quote(name)
+	}
+	return name
+}
+
+// isUnionText reports whether a rendered type is a union of several types
+// at its top level, as opposed to an object with a union field.
+func isUnionText(s string) bool {
+	depth := 0
+	inString := false
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case inString:
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '{' || c == '(' || c == '<':
+			depth++
+		case c == '}' || c == ')' || c == '>':
+			depth--
+		case depth == 0 && strings.HasPrefix(s[i:], " | "):
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package sap
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderFormatFields(t *testing.T) {
+	format, err := RenderFormat[testSchemaOrder]()
+	if err != nil {
+		t.Fatalf("RenderFormat failed: %v", err)
+	}
+
+	tests := []string{
+		"  // Order number\n  id: string,\n",
+		`  status: "open" | "closed",`,
+		"  currency?: string, // default: USD\n",
+		"  quantity: int, // between 1 and 99\n",
+		"  email: string, // at most 100 characters, email address\n",
+		"  codes: string[], // between 1 and 3 items, each matching ^[A-Z]+$\n",
+		"  pair: int[], // exactly 2 items\n",
+		"  notes?: string | null,\n",
+		"  placed: string, // RFC 3339 date-time\n",
+		"  extra: map<string, string>,\n",
+		"  any: any,\n",
+		`  priority: "Low" | "Medium" | "High",`,
+		"  created_by: string,\n",
+	}
+	for _, want := range tests {
+		if !strings.Contains(format, want) {
+			t.Errorf("format missing %q:\n%s", want, format)
+		}
+	}
+	if strings.Contains(format, "Internal") {
+		t.Errorf("skipped field rendered:\n%s", format)
+	}
+}
+
+func TestRenderFormatNested(t *testing.T) {
+	tests := []struct {
+		name   string
+		render func() (string, error)
+		want   string
+	}{
+		{"slice of structs", RenderFormat[[]TestUser], "{\n  name: string,\n  age: int,\n  email: string,\n}[]"},
+		{"enum descriptions", RenderFormat[testFeedback], "  // \"negative\": The customer is unhappy\n  mood: \"positive\" | \"negative\" | \"neutral\",\n"},
+		{"tagged union", RenderFormat[testToolCall], "  tool: {\n    type: \"calculator\",\n    expression: string,\n  } | {\n    type: \"search\",\n    query: string,\n  },\n"},
+		{"union slice", RenderFormat[testPets], "  pets: ({\n    kind: \"testDog\",\n    name: string,\n  } | {\n    kind: \"testCat\",\n    name: string,\n  })[],\n"},
+		{"recursive", RenderFormat[testSchemaNode], "testSchemaNode {\n  value: int,\n  children: (testSchemaNode | null)[],\n"},
+		{"required pointer", RenderFormat[testSchemaTicket], "{\n  owner: string,\n  reviewer?: string | null,\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.render()
+			if err != nil {
+				t.Fatalf("RenderFormat failed: %v", err)
+			}
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("format missing %q:\n%s", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestRenderFormatRoundTrip checks that an answer written as the format of
+// testSchemaOrder describes, with bare keys and optional fields left out,
+// parses.
+func TestRenderFormatRoundTrip(t *testing.T) {
+	input := `{
+		id: "A-1",
+		status: "open",
+		quantity: 3,
+		count: 1,
+		price: 9.5,
+		paid: true,
+		email: "ann@example.com",
+		codes: ["AB"],
+		pair: [1, 2],
+		notes: null,
+		placed: "2024-01-02T03:04:05Z",
+		extra: {"gift": "yes"},
+		any: 1,
+		priority: "High",
+		mood: "neutral",
+		created_by: "ann",
+	}`
+	order, err := Parse[testSchemaOrder](input)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if order.ID != "A-1" || order.Currency != "USD" || order.Pair != [2]int{1, 2} || order.CreatedBy != "ann" {
+		t.Errorf("unexpected result: %+v", order)
+	}
+}
+
+func TestRenderFormatErrors(t *testing.T) {
+	type badChan struct {
+		Events chan int `json:"events"`
+	}
+	type badTag struct {
+		Age int `json:"age" gsap:"min=old"`
+	}
+
+	if _, err := RenderFormat[badChan](); err == nil || !strings.Contains(err.Error(), "events") {
+		t.Errorf("expected error for chan field, got %v", err)
+	}
+	if _, err := RenderFormat[badTag](); err == nil || !strings.Contains(err.Error(), "age") {
+		t.Errorf("expected error for invalid tag, got %v", err)
+	}
+}
@@ -2,42 +2,95 @@ package sap
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
 )
 
-// DefaultParser is the default SAP parser instance
-var DefaultParser = NewParser()
+// DefaultParser is the default SAP parser instance, used by the generic
+// Parse helpers when they are given no options.
+var DefaultParser = New()
 
-// NewParser creates a new SAP parser with default options
-func NewParser() *sapParser {
-	return &sapParser{
-		options: &ParseOptions{
+// Parser extracts JSON from LLM output and coerces it to Go types.
+//
+// A Parser is immutable once built, so it is safe for concurrent use.
+// Configure one with New and options, or derive a variant from an existing
+// one with With or the With* methods, which return copies:
+//
+//	strict := sap.New(sap.WithStrict(), sap.WithMaxScore(10))
+//	lenient := strict.WithStrict(false)
+type Parser struct {
+	options          ParseOptions
+	coercers         map[reflect.Type]customCoercer // set by WithCoercer; never modified after New
+	schema           *schemaNode                    // set by WithJSONSchema
+	schemaErr        error                          // why the WithJSONSchema schema is invalid
+	extractor        *Extractor
+	partialExtractor *Extractor // accepts incomplete JSON for ParsePartial
+}
+
+// New creates a parser with the given options applied to the defaults.
+func New(opts ...Option) *Parser {
+	p := &Parser{
+		options: ParseOptions{
 			Streaming: StreamingOptions{
-				AllowIncompleteJSON: false,
+				AllowIncompleteJSON:  false,
 				TrackCompletionState: true,
 			},
 			Strict: false,
 		},
 	}
+	return p.build(opts)
+}
+
+// NewParser creates a new SAP parser with default options.
+// It is equivalent to New().
+func NewParser() *Parser {
+	return New()
+}
+
+// With returns a copy of p with opts applied. p itself is unchanged.
+func (p *Parser) With(opts ...Option) *Parser {
+	cp := *p
+	return cp.build(opts)
+}
+
+// build applies opts to p and prepares its extractors.
+func (p *Parser) build(opts []Option) *Parser {
+	for _, opt := range opts {
+		opt(p)
+	}
+	p.extractor = NewExtractor(&p.options)
+
+	// A partial parse reads a stream that has not finished yet
+	partial := p.options
+	partial.Streaming.AllowIncompleteJSON = true
+	p.partialExtractor = NewExtractor(&partial)
+	return p
 }
 
-// sapParser is the main parser implementation
-type sapParser struct {
-	options *ParseOptions
-	extractor *Extractor
-	coercer *TypeCoercer
+// parserFor returns DefaultParser, or a copy of it with opts applied.
+func parserFor(opts []Option) *Parser {
+	if len(opts) == 0 {
+		return DefaultParser
+	}
+	return DefaultParser.With(opts...)
 }
 
 // Parse parses input text into the target type
 // This is the main public API
-func Parse[T any](input string) (T, error) {
+//
+// If some fields fail to coerce, Parse returns the partial result along
+// with a *ParseError describing each failure. Options adjust the default
+// parser for this call:
+//
+//	user, err := sap.Parse[User](input, sap.WithStrict())
+func Parse[T any](input string, opts ...Option) (T, error) {
 	var zero T
-	result, err := DefaultParser.Parse(input, reflect.TypeOf(zero))
+	result, err := parserFor(opts).Parse(input, reflect.TypeOf((*T)(nil)).Elem())
+	typed, ok := result.(T)
 	if err != nil {
-		return zero, err
+		return typed, err
 	}
-	typed, ok := result.(T)
 	if !ok {
 		return zero, fmt.Errorf("type mismatch: expected %T, got %T", zero, result)
 	}
@@ -45,13 +98,13 @@ func Parse[T any](input string) (T, error) {
 }
 
 // ParseWithScore is like Parse but also returns the parse score
-func ParseWithScore[T any](input string) (T, *Score, error) {
+func ParseWithScore[T any](input string, opts ...Option) (T, *Score, error) {
 	var zero T
-	result, score, err := DefaultParser.ParseWithScore(input, reflect.TypeOf(zero))
+	result, score, err := parserFor(opts).ParseWithScore(input, reflect.TypeOf((*T)(nil)).Elem())
+	typed, ok := result.(T)
 	if err != nil {
-		return zero, nil, err
+		return typed, score, err
 	}
-	typed, ok := result.(T)
 	if !ok {
 		return zero, nil, fmt.Errorf("type mismatch: expected %T, got %T", zero, result)
 	}
@@ -59,51 +112,133 @@ func ParseWithScore[T any](input string) (T, *Score, error) {
 }
 
 // ParsePartial parses input as a partial type (for streaming)
-func ParsePartial[T any](input string) (T, CompletionState, error) {
+func ParsePartial[T any](input string, opts ...Option) (T, CompletionState, error) {
 	var zero T
-	result, state, err := DefaultParser.ParsePartial(input, reflect.TypeOf(zero))
+	result, state, err := parserFor(opts).ParsePartial(input, reflect.TypeOf((*T)(nil)).Elem())
+	typed, ok := result.(T)
 	if err != nil {
-		return zero, Complete, err
+		return typed, state, err
 	}
-	typed, ok := result.(T)
 	if !ok {
 		return zero, state, fmt.Errorf("type mismatch: expected %T, got %T", zero, result)
 	}
 	return typed, state, nil
 }
 
-// Parse implements the Parser interface
-func (p *sapParser) Parse(input string, targetType reflect.Type) (interface{}, error) {
+// ParseDetailed is like Parse but returns the full ParseResult, including
+// the paths of required fields missing from the input. The typed value is
+// returned separately for convenience.
+func ParseDetailed[T any](input string, opts ...Option) (T, *ParseResult, error) {
+	return typedResult[T](parserFor(opts).ParseDetailed(input, reflect.TypeOf((*T)(nil)).Elem()))
+}
+
+// ParsePartialDetailed is like ParsePartial but returns the full
+// ParseResult, including the required fields that have not arrived yet.
+func ParsePartialDetailed[T any](input string, opts ...Option) (T, *ParseResult, error) {
+	return typedResult[T](parserFor(opts).ParsePartialDetailed(input, reflect.TypeOf((*T)(nil)).Elem()))
+}
+
+// typedResult extracts the typed value from a ParseResult.
+func typedResult[T any](res *ParseResult, err error) (T, *ParseResult, error) {
+	var zero T
+	if res == nil {
+		return zero, nil, err
+	}
+	typed, ok := res.Value.(T)
+	if err != nil {
+		return typed, res, err
+	}
+	if !ok {
+		return zero, res, fmt.Errorf("type mismatch: expected %T, got %T", zero, res.Value)
+	}
+	return typed, res, nil
+}
+
+// Parse parses input into a value of targetType
+func (p *Parser) Parse(input string, targetType reflect.Type) (interface{}, error) {
 	result, _, err := p.ParseWithScore(input, targetType)
 	return result, err
 }
 
-// ParseWithScore extracts and parses JSON, returning the best match
-func (p *sapParser) ParseWithScore(input string, targetType reflect.Type) (interface{}, *Score, error) {
-	if p.extractor == nil {
-		p.extractor = NewExtractor(&ParseOptions{
-			Streaming: p.options.Streaming,
-			Strict:    p.options.Strict,
-		})
+// ParseWithScore extracts and parses JSON, returning the best match.
+//
+// Candidates whose fields all coerce beat candidates with failed fields;
+// among equals, the lowest score wins. If the best candidate has failed
+// fields, it is returned with a *ParseError.
+func (p *Parser) ParseWithScore(input string, targetType reflect.Type) (interface{}, *Score, error) {
+	res, err := p.parse(input, targetType, false)
+	if res == nil {
+		return nil, nil, err
+	}
+	return res.Value, res.Score, err
+}
+
+// ParseDetailed is like ParseWithScore but returns a ParseResult, which
+// also lists the required fields missing from the input.
+func (p *Parser) ParseDetailed(input string, targetType reflect.Type) (*ParseResult, error) {
+	return p.parse(input, targetType, false)
+}
+
+// ParsePartialDetailed is like ParsePartial but returns a ParseResult,
+// which also lists the required fields not yet present in the input.
+func (p *Parser) ParsePartialDetailed(input string, targetType reflect.Type) (*ParseResult, error) {
+	return p.parse(input, targetType, true)
+}
+
+// parse extracts and coerces the best candidate from input.
+//
+// Missing required fields are failures in a full parse. In a partial
+// parse they are expected, since the rest of the stream has not arrived
+// yet, so they only mark the result Incomplete.
+func (p *Parser) parse(input string, targetType reflect.Type, partial bool) (*ParseResult, error) {
+	if p.schemaErr != nil {
+		return nil, p.schemaErr
 	}
-	if p.coercer == nil {
-		p.coercer = NewTypeCoercer()
+	extractor := p.extractor
+	if partial {
+		extractor = p.partialExtractor
 	}
+	coercer := NewTypeCoercer()
+	coercer.required = p.options.Required
+	coercer.coercers = p.coercers
 
 	// Extract potential JSON candidates
-	candidates, err := p.extractor.ExtractJSON(input)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to extract JSON: %w", err)
+	candidates, err := extractor.ExtractJSON(input)
+	if err == nil && len(candidates) == 0 {
+		err = ErrNoJSON
 	}
-
-	if len(candidates) == 0 {
-		return nil, nil, fmt.Errorf("no JSON found in input")
+	if err != nil {
+		// Classification answers are often plain prose; look for an
+		// allowed enum or bool value mentioned in the text instead.
+		if !p.options.Strict {
+			score := &Score{flags: make(map[string]int)}
+			result, ok, textErr := coercer.coerceFromText(input, targetType, score)
+			if ok {
+				if textErr == nil && p.schema != nil {
+					var perr *ParseError
+					if perr, textErr = p.checkSchema(result, nil, nil, score); perr != nil {
+						textErr = perr
+					}
+				}
+				if textErr == nil {
+					textErr = p.checkMaxScore(score)
+				}
+				if textErr != nil {
+					return nil, fmt.Errorf("failed to parse: %w", textErr)
+				}
+				return &ParseResult{Value: result, Score: score, CompletionState: Complete}, nil
+			}
+		}
+		return nil, fmt.Errorf("failed to extract JSON: %w", err)
 	}
 
 	// Try to parse and coerce each candidate, pick the best
 	var bestResult interface{}
+	var bestPresence map[string]FieldPresence
+	var bestMatched int // fields of the best candidate found in the input
 	var bestScore *Score
-	var bestErr error
+	var bestErr *ParseError // failed fields of the best candidate, if any
+	var lastErr error       // why the most recent candidate failed outright
 
 	for _, candidate := range candidates {
 		// Unmarshal raw JSON
@@ -111,74 +246,173 @@ func (p *sapParser) ParseWithScore(input string, targetType reflect.Type) (inter
 		if err := json.Unmarshal([]byte(candidate.JSON), &rawValue); err != nil {
 			// If strict mode, skip on parse errors
 			if p.options.Strict {
+				lastErr = err
 				continue
 			}
 			// Otherwise, try to fix it
 			fixed, fixErr := FixJSON(candidate.JSON)
 			if fixErr != nil {
-				// Only track error if we don't have a successful result yet
-				if bestScore == nil {
-					bestErr = fixErr
-				}
+				lastErr = fixErr
 				continue
 			}
 			if err := json.Unmarshal([]byte(fixed), &rawValue); err != nil {
-				if bestScore == nil {
-					bestErr = err
-				}
+				lastErr = err
 				continue
 			}
 		}
 
 		// Coerce to target type
-		result, candScore, err := p.coercer.Coerce(rawValue, targetType)
+		result, candScore, err := coercer.Coerce(rawValue, targetType)
+		var candErr *ParseError
 		if err != nil {
-			// Only track error if we don't have a successful result yet
-			if bestScore == nil {
-				bestErr = err
+			if !errors.As(err, &candErr) || result == nil {
+				lastErr = err
+				continue
 			}
+			if p.options.Constraints == RejectViolations && errors.Is(candErr, ErrConstraint) {
+				lastErr = err
+				continue
+			}
+		}
+		presence := coercer.presenceMap()
+		if p.schema != nil {
+			if candErr, err = p.checkSchema(result, presence, candErr, candScore); err != nil {
+				lastErr = err
+				continue
+			}
+		}
+		if err := p.checkMaxScore(candScore); err != nil {
+			lastErr = err
 			continue
 		}
 
 		// Keep the best result
-		if bestScore == nil || candScore.Less(bestScore) {
+		matched := matchedFields(presence)
+		if bestScore == nil || betterCandidate(candErr, candScore, matched, bestErr, bestScore, bestMatched) {
 			bestResult = result
+			bestPresence = presence
+			bestMatched = matched
 			bestScore = candScore
+			bestErr = candErr
 		}
 	}
 
-	if bestErr != nil || bestScore == nil {
-		return nil, nil, fmt.Errorf("failed to parse: %w", bestErr)
+	if bestScore == nil {
+		if perr, ok := lastErr.(*ParseError); ok {
+			return nil, perr
+		}
+		return nil, fmt.Errorf("failed to parse: %w", lastErr)
+	}
+
+	res := &ParseResult{Value: bestResult, Score: bestScore, CompletionState: Complete, Presence: bestPresence}
+	if bestErr == nil {
+		return res, nil
 	}
 
-	return bestResult, bestScore, nil
+	// Separate missing fields from values that failed to coerce
+	var failed []FieldError
+	for _, fe := range bestErr.Errors {
+		if errors.Is(fe.Cause, ErrMissingField) {
+			res.Missing = append(res.Missing, fe.Path)
+		}
+		if !partial || !errors.Is(fe.Cause, ErrMissingField) {
+			failed = append(failed, fe)
+		}
+	}
+	if len(res.Missing) > 0 && (!partial || p.options.Streaming.TrackCompletionState) {
+		res.CompletionState = Incomplete
+	}
+	if len(failed) > 0 {
+		return res, &ParseError{Errors: failed}
+	}
+	return res, nil
 }
 
-// ParsePartial parses as a partial type (streaming)
-func (p *sapParser) ParsePartial(input string, targetType reflect.Type) (interface{}, CompletionState, error) {
-	result, _, err := p.ParseWithScore(input, targetType)
-	if err != nil {
-		return nil, Complete, err
+// checkMaxScore returns an error if score is above the parser's limit.
+func (p *Parser) checkMaxScore(score *Score) error {
+	if p.options.MaxScore > 0 && score.Total() > p.options.MaxScore {
+		return maxScoreError(score, p.options.MaxScore)
 	}
+	return nil
+}
+
+// maxScoreError reports that score is above max.
+func maxScoreError(score *Score, max int) error {
+	return fmt.Errorf("%w: score %d is above %d (flags: %v)", ErrMaxScore, score.Total(), max, score.Flags())
+}
 
-	// Determine completion state based on required fields
-	state := Complete
-	if p.options.Streaming.TrackCompletionState {
-		// TODO: Track which required fields are missing
-		state = Complete
+// betterCandidate reports whether a candidate with errors errs, score and
+// matched fields beats the current best: fewer failed fields first, then
+// more fields found in the input, then a lower score. Counting fields keeps
+// a nested object, which the extractor also offers as a candidate, from
+// beating the document around it just because it needed fewer coercions.
+func betterCandidate(errs *ParseError, score *Score, matched int, bestErrs *ParseError, bestScore *Score, bestMatched int) bool {
+	n, bestN := errorCount(errs), errorCount(bestErrs)
+	if n != bestN {
+		return n < bestN
 	}
+	if matched != bestMatched {
+		return matched > bestMatched
+	}
+	return score.Less(bestScore)
+}
 
-	return result, state, nil
+// matchedFields returns the number of struct fields, at any depth, that
+// were given a value, null or a null string in the input.
+func matchedFields(presence map[string]FieldPresence) int {
+	n := 0
+	for _, p := range presence {
+		if p == FieldPopulated || p == FieldNull || p == FieldNullString {
+			n++
+		}
+	}
+	return n
 }
 
-// WithStrict creates a new parser in strict mode (no fixing)
-func (p *sapParser) WithStrict(strict bool) *sapParser {
-	p.options.Strict = strict
-	return p
+// errorCount returns the number of failed fields in err.
+func errorCount(err *ParseError) int {
+	if err == nil {
+		return 0
+	}
+	return len(err.Errors)
 }
 
-// WithIncompleteJSON allows incomplete JSON for streaming
-func (p *sapParser) WithIncompleteJSON(allow bool) *sapParser {
-	p.options.Streaming.AllowIncompleteJSON = allow
-	return p
+// ParsePartial parses as a partial type (streaming).
+//
+// Required fields that have not arrived yet do not cause an error; they
+// make the state Incomplete instead.
+func (p *Parser) ParsePartial(input string, targetType reflect.Type) (interface{}, CompletionState, error) {
+	res, err := p.parse(input, targetType, true)
+	if res == nil {
+		return nil, Complete, err
+	}
+	return res.Value, res.CompletionState, err
+}
+
+// WithStrict returns a copy of p with strict mode (no fixing) set
+func (p *Parser) WithStrict(strict bool) *Parser {
+	return p.With(func(p *Parser) { p.options.Strict = strict })
+}
+
+// WithRequiredPolicy returns a copy of p that requires fields by policy
+func (p *Parser) WithRequiredPolicy(policy RequiredPolicy) *Parser {
+	return p.With(WithRequiredPolicy(policy))
+}
+
+// WithConstraintPolicy returns a copy of p that handles constraint
+// violations by policy
+func (p *Parser) WithConstraintPolicy(policy ConstraintPolicy) *Parser {
+	return p.With(WithConstraintPolicy(policy))
+}
+
+// WithIncompleteJSON returns a copy of p that allows incomplete JSON for
+// streaming
+func (p *Parser) WithIncompleteJSON(allow bool) *Parser {
+	return p.With(func(p *Parser) { p.options.Streaming.AllowIncompleteJSON = allow })
+}
+
+// WithMaxScore returns a copy of p that rejects candidates scoring above
+// n; 0 removes the limit
+func (p *Parser) WithMaxScore(n int) *Parser {
+	return p.With(WithMaxScore(n))
 }
@@ -2,8 +2,12 @@ package sap
 
 import (
 	"encoding/json"
+	"errors"
 	"reflect"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 type TestUser struct {
@@ -686,6 +690,312 @@ func TestParsePartialInvalidJSON(t *testing.T) {
 	}
 }
 
+// --- Tests for required fields ---
+
+type testSignup struct {
+	Username string  `json:"username" gsap:"required"`
+	Email    string  `json:"email" gsap:"required"`
+	Plan     string  `json:"plan,omitempty"`
+	Referrer *string `json:"referrer"`
+	Address  struct {
+		City string `json:"city" gsap:"required"`
+	} `json:"address"`
+}
+
+func TestParseRequiredFieldMissing(t *testing.T) {
+	result, res, err := ParseDetailed[testSignup](`{"username": "ada", "email": null, "address": {}}`)
+
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected *ParseError, got %v", err)
+	}
+	if !errors.Is(err, ErrMissingField) {
+		t.Errorf("expected errors.Is(err, ErrMissingField), got %v", err)
+	}
+	wantMissing := []string{"email", "address.city"}
+	if !reflect.DeepEqual(res.Missing, wantMissing) {
+		t.Errorf("Missing = %v, want %v", res.Missing, wantMissing)
+	}
+	if len(perr.Errors) != len(wantMissing) {
+		t.Errorf("expected %d field errors, got %v", len(wantMissing), perr.Errors)
+	}
+	if res.CompletionState != Incomplete {
+		t.Errorf("CompletionState = %v, want Incomplete", res.CompletionState)
+	}
+	if result.Username != "ada" {
+		t.Errorf("expected partial result with username, got %+v", result)
+	}
+}
+
+func TestParsePartialRequiredFields(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		wantState   CompletionState
+		wantMissing []string
+	}{
+		{
+			name:      "all required present",
+			input:     `{"username": "ada", "email": "ada@example.com", "address": {"city": "London"}}`,
+			wantState: Complete,
+		},
+		{
+			name:        "truncated stream",
+			input:       `{"username": "ada", "email": "ada@exa`,
+			wantState:   Incomplete,
+			wantMissing: []string{"address.city"},
+		},
+		{
+			name:        "cut off inside a key",
+			input:       `{"username": "ada", "ema`,
+			wantState:   Incomplete,
+			wantMissing: []string{"email", "address.city"},
+		},
+		{
+			name:        "only username so far",
+			input:       `{"username": "ada"`,
+			wantState:   Incomplete,
+			wantMissing: []string{"email", "address.city"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, state, err := ParsePartial[testSignup](tt.input)
+			if err != nil {
+				t.Fatalf("ParsePartial failed: %v", err)
+			}
+			if state != tt.wantState {
+				t.Errorf("CompletionState = %v, want %v", state, tt.wantState)
+			}
+
+			_, res, err := ParsePartialDetailed[testSignup](tt.input)
+			if err != nil {
+				t.Fatalf("ParsePartialDetailed failed: %v", err)
+			}
+			if !reflect.DeepEqual(res.Missing, tt.wantMissing) {
+				t.Errorf("Missing = %v, want %v", res.Missing, tt.wantMissing)
+			}
+		})
+	}
+}
+
+func TestParseRequireNonOptionalPolicy(t *testing.T) {
+	input := `{"username": "ada", "email": "ada@example.com", "address": {"city": "London"}}`
+
+	// Plan is omitempty and Referrer is a pointer, so neither is required
+	parser := NewParser().WithRequiredPolicy(RequireNonOptional)
+	if _, err := parser.Parse(input, reflect.TypeOf(testSignup{})); err != nil {
+		t.Errorf("expected optional fields to be allowed missing, got %v", err)
+	}
+
+	res, err := parser.ParseDetailed(`{"name": "Alice"}`, reflect.TypeOf(TestUser{}))
+	if !errors.Is(err, ErrMissingField) {
+		t.Fatalf("expected ErrMissingField, got %v", err)
+	}
+	if want := []string{"age", "email"}; !reflect.DeepEqual(res.Missing, want) {
+		t.Errorf("Missing = %v, want %v", res.Missing, want)
+	}
+
+	// The default policy only requires tagged fields
+	if _, err := Parse[TestUser](`{"name": "Alice"}`); err != nil {
+		t.Errorf("expected untagged fields to be optional by default, got %v", err)
+	}
+}
+
+// --- Tests for field presence ---
+
+type testContact struct {
+	Name     string  `json:"name"`
+	Phone    string  `json:"phone"`
+	Age      int     `json:"age"`
+	Nickname *string `json:"nickname"`
+	Emails   []struct {
+		Address string `json:"address"`
+		Primary bool   `json:"primary"`
+	} `json:"emails"`
+}
+
+func TestParsePresence(t *testing.T) {
+	input := `{"name": "Ada", "age": "N/A", "nickname": null, "emails": [{"address": "ada@example.com"}]}`
+	_, res, err := ParseDetailed[testContact](input)
+	if err != nil {
+		t.Fatalf("ParseDetailed failed: %v", err)
+	}
+
+	want := map[string]FieldPresence{
+		"name":              FieldPopulated,
+		"phone":             FieldMissing,
+		"age":               FieldNullString,
+		"nickname":          FieldNull,
+		"emails":            FieldPopulated,
+		"emails[0].address": FieldPopulated,
+		"emails[0].primary": FieldMissing,
+	}
+	if !reflect.DeepEqual(res.Presence, want) {
+		t.Errorf("Presence = %v, want %v", res.Presence, want)
+	}
+
+	if !res.Populated("name") || res.Populated("phone") || res.Populated("age") {
+		t.Errorf("Populated disagrees with Presence %v", res.Presence)
+	}
+
+	contact, res, err := ParseDetailed[testContact](`{"name": "Ada", "nickname": "unknown"}`)
+	if err != nil {
+		t.Fatalf("ParseDetailed failed: %v", err)
+	}
+	if contact.Nickname != nil || res.Presence["nickname"] != FieldNullString {
+		t.Errorf("expected nil nickname from a null string, got %v, %v", contact.Nickname, res.Presence)
+	}
+}
+
+func TestParsePresenceSkipsFailedFields(t *testing.T) {
+	_, res, err := ParseDetailed[TestUser](`{"name": "Ada", "age": "old"}`)
+	if err == nil {
+		t.Fatal("expected an error for age")
+	}
+	if _, ok := res.Presence["age"]; ok {
+		t.Errorf("expected no presence for failed field, got %v", res.Presence)
+	}
+	if res.Presence["email"] != FieldMissing {
+		t.Errorf("email presence = %v, want FieldMissing", res.Presence["email"])
+	}
+}
+
+// --- Tests for default values ---
+
+type testTicketDefaults struct {
+	Title    string       `json:"title"`
+	Status   string       `json:"status" gsap:"enum=open|closed,default=open"`
+	Priority testPriority `json:"priority" gsap:"default=Medium"`
+	Points   int          `json:"points" gsap:"default=3,required"`
+	Labels   []string     `json:"labels" gsap:"default='triage, new'"`
+	Due      time.Time    `json:"due" gsap:"default=2025-01-31"`
+	Owner    *string      `json:"owner" gsap:"default=unassigned"`
+}
+
+func TestParseDefaults(t *testing.T) {
+	input := `{"title": "Crash on save", "status": "TBD", "points": null}`
+	got, res, err := ParseDetailed[testTicketDefaults](input)
+	if err != nil {
+		t.Fatalf("ParseDetailed failed: %v", err)
+	}
+
+	if got.Status != "open" {
+		t.Errorf("Status = %q, want open", got.Status)
+	}
+	if got.Priority != testPriorityMedium {
+		t.Errorf("Priority = %v, want Medium", got.Priority)
+	}
+	if got.Points != 3 {
+		t.Errorf("Points = %d, want 3", got.Points)
+	}
+	if !reflect.DeepEqual(got.Labels, []string{"triage", "new"}) {
+		t.Errorf("Labels = %v, want [triage new]", got.Labels)
+	}
+	if want := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC); !got.Due.Equal(want) {
+		t.Errorf("Due = %v, want %v", got.Due, want)
+	}
+	if got.Owner == nil || *got.Owner != "unassigned" {
+		t.Errorf("Owner = %v, want unassigned", got.Owner)
+	}
+
+	for _, path := range []string{"status", "priority", "points", "labels", "due", "owner"} {
+		if res.Presence[path] != FieldDefaulted {
+			t.Errorf("Presence[%s] = %v, want FieldDefaulted", path, res.Presence[path])
+		}
+	}
+	if _, ok := res.Score.Flags()[FlagDefaultApplied]; !ok {
+		t.Errorf("expected %s flag, got %v", FlagDefaultApplied, res.Score.Flags())
+	}
+}
+
+func TestParseDefaultsNotUsedForInputValues(t *testing.T) {
+	input := `{"title": "x", "status": "closed", "priority": "high", "points": "5", "labels": [], "owner": "sam"}`
+	got, score, err := ParseWithScore[testTicketDefaults](input)
+	if err != nil {
+		t.Fatalf("ParseWithScore failed: %v", err)
+	}
+	if got.Status != "closed" || got.Priority != testPriorityHigh || got.Points != 5 || *got.Owner != "sam" {
+		t.Errorf("input values were overridden: %+v", got)
+	}
+	if len(got.Labels) != 0 {
+		t.Errorf("Labels = %v, want the empty input list", got.Labels)
+	}
+	if score.Flags()[FlagDefaultApplied] == 0 {
+		t.Errorf("expected the default for due to be flagged, got %v", score.Flags())
+	}
+}
+
+type testRiskKind string
+
+type testRiskLevel int
+
+func (l testRiskLevel) String() string {
+	switch l {
+	case 0:
+		return "none"
+	case 1:
+		return "low"
+	case 2:
+		return "high"
+	}
+	return "testRiskLevel(" + strconv.Itoa(int(l)) + ")"
+}
+
+func init() {
+	RegisterEnum[testRiskKind]("none", "low", "high")
+}
+
+type testRiskDefaults struct {
+	Risk  string        `json:"risk" gsap:"enum=none|low|high,default=low"`
+	Kind  testRiskKind  `json:"kind" gsap:"default=low"`
+	Level testRiskLevel `json:"level" gsap:"default=low"`
+}
+
+func TestParseDefaultsKeepNullLikeEnumMembers(t *testing.T) {
+	got, res, err := ParseDetailed[testRiskDefaults](`{"risk": "none", "kind": "None", "level": "none"}`)
+	if err != nil {
+		t.Fatalf("ParseDetailed failed: %v", err)
+	}
+	if want := (testRiskDefaults{"none", "none", 0}); got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	for _, path := range []string{"risk", "kind", "level"} {
+		if res.Presence[path] != FieldPopulated {
+			t.Errorf("Presence[%s] = %v, want FieldPopulated", path, res.Presence[path])
+		}
+	}
+
+	// Null-like strings that aren't members still fall back to the default
+	got, res, err = ParseDetailed[testRiskDefaults](`{"risk": "N/A", "kind": "TBD", "level": "n/a"}`)
+	if err != nil {
+		t.Fatalf("ParseDetailed failed: %v", err)
+	}
+	if want := (testRiskDefaults{"low", "low", 1}); got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	for _, path := range []string{"risk", "kind", "level"} {
+		if res.Presence[path] != FieldDefaulted {
+			t.Errorf("Presence[%s] = %v, want FieldDefaulted", path, res.Presence[path])
+		}
+	}
+}
+
+func TestParseInvalidDefault(t *testing.T) {
+	type broken struct {
+		Count int `json:"count" gsap:"default=many"`
+	}
+	_, err := Parse[broken](`{}`)
+	var perr *ParseError
+	if !errors.As(err, &perr) || perr.Errors[0].Path != "count" {
+		t.Fatalf("expected a field error for count, got %v", err)
+	}
+	if !strings.Contains(err.Error(), `invalid default "many"`) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
 // --- Tests for InstructorParser configuration ---
 
 func TestInstructorParserWithStrict(t *testing.T) {
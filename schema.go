@@ -0,0 +1,458 @@
+package sap
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+)
+
+// jsonSchemaDraft identifies the JSON Schema version JSONSchema produces.
+const jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// schemaTask names JSONSchema's work in its errors.
+const schemaTask = "generate JSON Schema"
+
+// schemaFormats maps format= options to JSON Schema formats.
+var schemaFormats = map[string]string{
+	"email":     "email",
+	"uuid":      "uuid",
+	"url":       "uri",
+	"date":      "date",
+	"date-time": "date-time",
+}
+
+// reDefNameChars matches characters not allowed in a $defs name.
+var reDefNameChars = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// SchemaOption configures JSONSchema.
+type SchemaOption func(*schemaBuilder)
+
+// StrictSchema makes JSONSchema produce a schema for the strict structured
+// output modes of LLM providers: every property of every object is
+// required and no others are allowed. Fields that are optional to Parse
+// accept null instead of being left out.
+func StrictSchema() SchemaOption {
+	return func(b *schemaBuilder) { b.strict = true }
+}
+
+// JSONSchema returns a JSON Schema (draft 2020-12) describing the JSON
+// that Parse[T] expects, ready to be marshaled into a tool definition or
+// response format:
+//
+//	schema, err := sap.JSONSchema[User]()
+//	data, _ := json.Marshal(schema)
+//
+// Properties are named as Parse matches them, by json tag or field name,
+// with embedded structs flattened. Registered enums and unions become enum
+// and anyOf schemas, `gsap` tag constraints and defaults carry over, and
+// `description` tags become descriptions. Fields tagged
+// `gsap:"required"` are required. Named struct types are defined once
+// under $defs, so recursive types are supported.
+//
+// It returns an error for types that can't be parsed from JSON, such as
+// channels and funcs, and for malformed `gsap` tags.
+func JSONSchema[T any](opts ...SchemaOption) (map[string]interface{}, error) {
+	return jsonSchemaFor(reflect.TypeOf((*T)(nil)).Elem(), opts...)
+}
+
+// jsonSchemaFor builds the JSON Schema document for t.
+func jsonSchemaFor(t reflect.Type, opts ...SchemaOption) (map[string]interface{}, error) {
+	b := &schemaBuilder{
+		defs: make(map[string]interface{}),
+		refs: make(map[reflect.Type]string),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	var schema map[string]interface{}
+	var err error
+	if t.Kind() == reflect.Struct && t != timeType && !isUnmarshaler(t) {
+		// The root struct is described inline; it refers to itself as "#"
+		b.refs[t] = "#"
+		schema, err = b.object(t, "")
+	} else {
+		schema, err = b.schema(t, fieldOptions{}, "")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	schema["$schema"] = jsonSchemaDraft
+	if len(b.defs) > 0 {
+		schema["$defs"] = b.defs
+	}
+	return schema, nil
+}
+
+// schemaBuilder accumulates the definitions of a JSON Schema document.
+type schemaBuilder struct {
+	strict bool
+	defs   map[string]interface{}  // $defs, by name
+	refs   map[reflect.Type]string // $ref of each defined struct type
+}
+
+// schema describes values of type t in a field with the given options,
+// which apply to the elements of slices and arrays as in coercion.
+func (b *schemaBuilder) schema(t reflect.Type, opts fieldOptions, path string) (map[string]interface{}, error) {
+	shape, err := shapeOf(t, opts)
+	if err != nil {
+		return nil, describeError(schemaTask, path, err)
+	}
+
+	switch shape.kind {
+	case shapeTime:
+		return map[string]interface{}{"type": "string", "format": "date-time"}, nil
+	case shapeText:
+		return stringSchema(opts, path)
+	case shapeRaw, shapeAny:
+		// Anything goes for a type that decodes itself from arbitrary JSON
+		// and for an interface that isn't a union
+		return map[string]interface{}{}, nil
+	case shapePointer:
+		s, err := b.schema(t.Elem(), opts, path)
+		if err != nil {
+			return nil, err
+		}
+		return nullable(s), nil
+	case shapeUnion:
+		return b.union(shape.union, path)
+	case shapeEnum:
+		return enumSchema(shape.enum), nil
+	case shapeString:
+		return stringSchema(opts, path)
+	case shapeInt:
+		return numberSchema("integer", opts), nil
+	case shapeUint:
+		s := numberSchema("integer", opts)
+		if s["minimum"] == nil {
+			s["minimum"] = 0
+		}
+		return s, nil
+	case shapeFloat:
+		return numberSchema("number", opts), nil
+	case shapeBool:
+		return map[string]interface{}{"type": "boolean"}, nil
+	case shapeList:
+		elemOpts := opts
+		elemOpts.minLen, elemOpts.maxLen = nil, nil
+		items, err := b.schema(t.Elem(), elemOpts, path+"[]")
+		if err != nil {
+			return nil, err
+		}
+		s := map[string]interface{}{"type": "array", "items": items}
+		if t.Kind() == reflect.Array {
+			s["minItems"], s["maxItems"] = t.Len(), t.Len()
+		}
+		setLen(s, opts, "minItems", "maxItems")
+		return s, nil
+	case shapeMap:
+		values, err := b.schema(t.Elem(), fieldOptions{}, path+"{}")
+		if err != nil {
+			return nil, err
+		}
+		s := map[string]interface{}{"type": "object", "additionalProperties": values}
+		setLen(s, opts, "minProperties", "maxProperties")
+		return s, nil
+	default: // shapeStruct
+		return b.structRef(t, path)
+	}
+}
+
+// structRef returns a reference to the definition of struct type t,
+// adding it to $defs on first use. Anonymous structs are described inline.
+func (b *schemaBuilder) structRef(t reflect.Type, path string) (map[string]interface{}, error) {
+	if ref, ok := b.refs[t]; ok {
+		return map[string]interface{}{"$ref": ref}, nil
+	}
+	if t.Name() == "" {
+		return b.object(t, path)
+	}
+
+	name := reDefNameChars.ReplaceAllString(t.Name(), "_")
+	for i := 2; b.defs[name] != nil; i++ {
+		name = reDefNameChars.ReplaceAllString(t.Name(), "_") + strconv.Itoa(i)
+	}
+	ref := "#/$defs/" + name
+	b.refs[t] = ref
+	b.defs[name] = true // reserve the name while the definition is built
+
+	s, err := b.object(t, path)
+	if err != nil {
+		return nil, err
+	}
+	b.defs[name] = s
+	return map[string]interface{}{"$ref": ref}, nil
+}
+
+// object describes struct type t as an object with a property for each
+// field that Parse fills.
+func (b *schemaBuilder) object(t reflect.Type, path string) (map[string]interface{}, error) {
+	plan := structPlanFor(t)
+	properties := make(map[string]interface{}, len(plan.fields))
+	required := []string{}
+
+	for i := range plan.fields {
+		fp := &plan.fields[i]
+		if fp.opts.skip {
+			continue
+		}
+		name := fp.pathName
+		fieldType, isRequired := fp.describedType()
+		s, err := b.schema(fieldType, fp.opts, joinSchemaPath(path, name))
+		if err != nil {
+			return nil, err
+		}
+
+		if b.strict {
+			if !isRequired {
+				s = nullable(s)
+			}
+			isRequired = true
+		}
+		if fp.opts.description != "" {
+			s["description"] = fp.opts.description
+		}
+		if fp.opts.hasDefault {
+			def, err := defaultJSON(fp)
+			if err != nil {
+				return nil, describeError(schemaTask, joinSchemaPath(path, name), err)
+			}
+			s["default"] = def
+		}
+
+		properties[name] = s
+		if isRequired {
+			required = append(required, name)
+		}
+	}
+
+	s := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	if b.strict {
+		s["additionalProperties"] = false
+	}
+	return s, nil
+}
+
+// union describes a registered union as anyOf its variants. For a
+// discriminated union, each variant is described inline with its
+// discriminator value as a required constant.
+func (b *schemaBuilder) union(def *unionDef, path string) (map[string]interface{}, error) {
+	var tags []string
+	if def.discriminator != "" {
+		tags = def.tagNames()
+	}
+
+	variants := make([]interface{}, len(def.variants))
+	for i, variant := range def.variants {
+		for variant.Kind() == reflect.Ptr {
+			variant = variant.Elem()
+		}
+		if tags == nil || variant.Kind() != reflect.Struct {
+			s, err := b.schema(variant, fieldOptions{}, path)
+			if err != nil {
+				return nil, err
+			}
+			variants[i] = s
+			continue
+		}
+
+		s, err := b.object(variant, path)
+		if err != nil {
+			return nil, err
+		}
+		s["properties"].(map[string]interface{})[def.discriminator] = map[string]interface{}{"const": tags[i]}
+		required, _ := s["required"].([]string)
+		if !containsString(required, def.discriminator) {
+			s["required"] = append([]string{def.discriminator}, required...)
+		}
+		variants[i] = s
+	}
+	return map[string]interface{}{"anyOf": variants}, nil
+}
+
+// enumSchema describes the values of an enum by name. Values with
+// descriptions are listed as constants so that each can carry its own.
+func enumSchema(def *enumDef) map[string]interface{} {
+	hasDescriptions := false
+	for i := range def.names {
+		if def.description(i) != "" {
+			hasDescriptions = true
+		}
+	}
+	if !hasDescriptions {
+		return map[string]interface{}{"type": "string", "enum": append([]string(nil), def.names...)}
+	}
+
+	values := make([]interface{}, len(def.names))
+	for i, name := range def.names {
+		v := map[string]interface{}{"const": name}
+		if d := def.description(i); d != "" {
+			v["description"] = d
+		}
+		values[i] = v
+	}
+	return map[string]interface{}{"type": "string", "oneOf": values}
+}
+
+// stringSchema describes a string with the given length, pattern and
+// format constraints.
+func stringSchema(opts fieldOptions, path string) (map[string]interface{}, error) {
+	s := map[string]interface{}{"type": "string"}
+	setLen(s, opts, "minLength", "maxLength")
+	if opts.pattern != "" {
+		if _, err := compilePattern(opts.pattern); err != nil {
+			return nil, describeError(schemaTask, path, err)
+		}
+		s["pattern"] = opts.pattern
+	}
+	if opts.format != "" {
+		format, ok := schemaFormats[opts.format]
+		if !ok {
+			return nil, describeError(schemaTask, path, fmt.Errorf("unknown format %q in gsap tag", opts.format))
+		}
+		s["format"] = format
+	}
+	return s, nil
+}
+
+// numberSchema describes a number of the given JSON type with min= and
+// max= bounds.
+func numberSchema(typ string, opts fieldOptions) map[string]interface{} {
+	s := map[string]interface{}{"type": typ}
+	if opts.min != nil {
+		s["minimum"] = *opts.min
+	}
+	if opts.max != nil {
+		s["maximum"] = *opts.max
+	}
+	return s
+}
+
+// setLen sets the keywords for a len= option.
+func setLen(s map[string]interface{}, opts fieldOptions, minKey, maxKey string) {
+	if opts.minLen != nil {
+		s[minKey] = *opts.minLen
+	}
+	if opts.maxLen != nil {
+		s[maxKey] = *opts.maxLen
+	}
+}
+
+// nullable allows null in place of the values s describes.
+func nullable(s map[string]interface{}) map[string]interface{} {
+	if allowsNull(s) {
+		return s
+	}
+	typ, ok := s["type"].(string)
+	_, hasEnum := s["enum"]
+	_, hasOneOf := s["oneOf"]
+	if !ok || hasEnum || hasOneOf {
+		return map[string]interface{}{"anyOf": []interface{}{s, map[string]interface{}{"type": "null"}}}
+	}
+	s["type"] = []string{typ, "null"}
+	return s
+}
+
+// allowsNull reports whether s was made nullable already.
+func allowsNull(s map[string]interface{}) bool {
+	if types, ok := s["type"].([]string); ok {
+		return containsString(types, "null")
+	}
+	if anyOf, ok := s["anyOf"].([]interface{}); ok && len(anyOf) == 2 {
+		last, _ := anyOf[1].(map[string]interface{})
+		return len(last) == 1 && last["type"] == "null"
+	}
+	return false
+}
+
+// defaultJSON returns a field's default value as it would appear in JSON.
+func defaultJSON(fp *fieldPlan) (interface{}, error) {
+	v, err := tagValueJSON(fp.opts.defaultValue, fp.field.Type, fp.opts)
+	if err != nil {
+		return nil, fmt.Errorf("invalid default %q: %w", fp.opts.defaultValue, err)
+	}
+	return v, nil
+}
+
+// tagValueJSON coerces raw, a value given in a tag for a field of type t,
+// as Parse coerces defaults, and returns the result as JSON.
+func tagValueJSON(raw interface{}, t reflect.Type, opts fieldOptions) (interface{}, error) {
+	v, err := NewTypeCoercer().coerceWithOptions(raw, t, opts, &Score{})
+	if err != nil {
+		return nil, err
+	}
+	return jsonValue(v, t, opts)
+}
+
+// jsonValue returns v, a value of type t coerced for a field with opts, as
+// decoded JSON that Parse turns back into v. Integer enums become their
+// names, which is what the schema lists, rather than numbers.
+func jsonValue(v interface{}, t reflect.Type, opts fieldOptions) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	shape, err := shapeOf(t, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	rv := reflect.ValueOf(v)
+	switch shape.kind {
+	case shapePointer:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		return jsonValue(rv.Elem().Interface(), t.Elem(), opts)
+	case shapeList:
+		if t.Kind() == reflect.Slice && rv.IsNil() {
+			return nil, nil
+		}
+		items := make([]interface{}, rv.Len())
+		for i := range items {
+			item, err := jsonValue(rv.Index(i).Interface(), t.Elem(), opts)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+	case shapeEnum:
+		for i, value := range shape.enum.values {
+			if value.Interface() == v {
+				return shape.enum.names[i], nil
+			}
+		}
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out interface{}
+	err = json.Unmarshal(data, &out)
+	return out, err
+}
+
+// joinSchemaPath appends a property name to a path, e.g. "items[].price".
+func joinSchemaPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// containsString reports whether list contains s.
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,241 @@
+package sap
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// schemaProperty marshals the schema of property name in schema.
+func schemaProperty(t *testing.T, schema map[string]interface{}, name string) string {
+	t.Helper()
+	props, ok := schema["properties"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("schema has no properties: %v", schema)
+	}
+	data, err := json.Marshal(props[name])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+type testSchemaOrder struct {
+	ID       string            `json:"id" gsap:"required" description:"Order number"`
+	Status   string            `json:"status" gsap:"enum=open|closed"`
+	Currency string            `json:"currency" gsap:"default=USD"`
+	Quantity int               `json:"quantity" gsap:"min=1,max=99"`
+	Count    uint              `json:"count"`
+	Price    float64           `json:"price"`
+	Paid     bool              `json:"paid"`
+	Email    string            `json:"email" gsap:"format=email,len=..100"`
+	Codes    []string          `json:"codes" gsap:"len=1..3,pattern=^[A-Z]+$"`
+	Pair     [2]int            `json:"pair"`
+	Notes    *string           `json:"notes,omitempty"`
+	Placed   time.Time         `json:"placed"`
+	Extra    map[string]string `json:"extra"`
+	Any      interface{}       `json:"any"`
+	Internal string            `json:"-"`
+	Priority testPriority      `json:"priority"`
+	Mood     testSentiment     `json:"mood"`
+	TestMeta
+}
+
+func TestJSONSchemaProperties(t *testing.T) {
+	schema, err := JSONSchema[testSchemaOrder]()
+	if err != nil {
+		t.Fatalf("JSONSchema failed: %v", err)
+	}
+	if schema["$schema"] != jsonSchemaDraft || schema["type"] != "object" {
+		t.Errorf("unexpected root: %v", schema)
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"id", `{"description":"Order number","type":"string"}`},
+		{"status", `{"enum":["open","closed"],"type":"string"}`},
+		{"currency", `{"default":"USD","type":"string"}`},
+		{"quantity", `{"maximum":99,"minimum":1,"type":"integer"}`},
+		{"count", `{"minimum":0,"type":"integer"}`},
+		{"price", `{"type":"number"}`},
+		{"paid", `{"type":"boolean"}`},
+		{"email", `{"format":"email","maxLength":100,"type":"string"}`},
+		{"codes", `{"items":{"pattern":"^[A-Z]+$","type":"string"},"maxItems":3,"minItems":1,"type":"array"}`},
+		{"pair", `{"items":{"type":"integer"},"maxItems":2,"minItems":2,"type":"array"}`},
+		{"notes", `{"type":["string","null"]}`},
+		{"placed", `{"format":"date-time","type":"string"}`},
+		{"extra", `{"additionalProperties":{"type":"string"},"type":"object"}`},
+		{"any", `{}`},
+		{"Internal", `null`},
+		{"priority", `{"enum":["Low","Medium","High"],"type":"string"}`},
+		{"mood", `{"enum":["positive","negative","neutral"],"type":"string"}`},
+		{"created_by", `{"type":"string"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := schemaProperty(t, schema, tt.name); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	required, _ := schema["required"].([]string)
+	if strings.Join(required, ",") != "id" {
+		t.Errorf("expected only id to be required, got %v", required)
+	}
+}
+
+type testSchemaAddress struct {
+	Street string `json:"street"`
+	City   string `json:"city" gsap:"required"`
+}
+
+type testSchemaPerson struct {
+	Name    string             `json:"name"`
+	Address *testSchemaAddress `json:"address"`
+}
+
+func TestJSONSchemaStrict(t *testing.T) {
+	schema, err := JSONSchema[testSchemaPerson](StrictSchema())
+	if err != nil {
+		t.Fatalf("JSONSchema failed: %v", err)
+	}
+	data, _ := json.Marshal(schema)
+	want := `{"$defs":{"testSchemaAddress":{"additionalProperties":false,"properties":{"city":{"type":"string"},"street":{"type":["string","null"]}},"required":["street","city"],"type":"object"}},` +
+		`"$schema":"https://json-schema.org/draft/2020-12/schema","additionalProperties":false,` +
+		`"properties":{"address":{"anyOf":[{"$ref":"#/$defs/testSchemaAddress"},{"type":"null"}]},"name":{"type":["string","null"]}},` +
+		`"required":["name","address"],"type":"object"}`
+	if string(data) != want {
+		t.Errorf("got  %s\nwant %s", data, want)
+	}
+}
+
+type testSchemaNode struct {
+	Value    int               `json:"value"`
+	Children []*testSchemaNode `json:"children"`
+	Next     *testSchemaList   `json:"next"`
+}
+
+type testSchemaList struct {
+	Head *testSchemaNode `json:"head"`
+}
+
+func TestJSONSchemaRecursive(t *testing.T) {
+	schema, err := JSONSchema[testSchemaNode]()
+	if err != nil {
+		t.Fatalf("JSONSchema failed: %v", err)
+	}
+	if got := schemaProperty(t, schema, "children"); got != `{"items":{"anyOf":[{"$ref":"#"},{"type":"null"}]},"type":"array"}` {
+		t.Errorf("unexpected children schema: %s", got)
+	}
+	if got := schemaProperty(t, schema, "next"); got != `{"anyOf":[{"$ref":"#/$defs/testSchemaList"},{"type":"null"}]}` {
+		t.Errorf("unexpected next schema: %s", got)
+	}
+}
+
+func TestJSONSchemaUnions(t *testing.T) {
+	schema, err := JSONSchema[testDrawing]()
+	if err != nil {
+		t.Fatalf("JSONSchema failed: %v", err)
+	}
+	want := `{"anyOf":[{"$ref":"#/$defs/testCircle"},{"$ref":"#/$defs/testSquare"},{"$ref":"#/$defs/testRect"}]}`
+	if got := schemaProperty(t, schema, "main"); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+
+	schema, err = JSONSchema[testToolCall]()
+	if err != nil {
+		t.Fatalf("JSONSchema failed: %v", err)
+	}
+	want = `{"anyOf":[` +
+		`{"properties":{"expression":{"type":"string"},"type":{"const":"calculator"}},"required":["type"],"type":"object"},` +
+		`{"properties":{"query":{"type":"string"},"type":{"const":"search"}},"required":["type"],"type":"object"}]}`
+	if got := schemaProperty(t, schema, "tool"); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+
+	// A discriminator tag on the field tags variants by type name
+	schema, err = JSONSchema[testPets]()
+	if err != nil {
+		t.Fatalf("JSONSchema failed: %v", err)
+	}
+	if got := schemaProperty(t, schema, "pets"); !strings.Contains(got, `"kind":{"const":"testDog"}`) {
+		t.Errorf("expected kind constants, got %s", got)
+	}
+}
+
+func TestJSONSchemaEnumDescriptions(t *testing.T) {
+	schema, err := JSONSchema[testFeedback]()
+	if err != nil {
+		t.Fatalf("JSONSchema failed: %v", err)
+	}
+	got := schemaProperty(t, schema, "mood")
+	if !strings.Contains(got, `{"const":"negative","description":"The customer is unhappy"}`) {
+		t.Errorf("expected described constants, got %s", got)
+	}
+}
+
+type testSchemaTicket struct {
+	Owner    *string      `json:"owner" gsap:"required"`
+	Reviewer *string      `json:"reviewer"`
+	Urgency  testPriority `json:"urgency" gsap:"default=high"`
+	Labels   []string     `json:"labels" gsap:"default='bug, ui'"`
+}
+
+func TestJSONSchemaRequiredPointersAndDefaults(t *testing.T) {
+	for _, strict := range []bool{false, true} {
+		var opts []SchemaOption
+		if strict {
+			opts = append(opts, StrictSchema())
+		}
+		schema, err := JSONSchema[testSchemaTicket](opts...)
+		if err != nil {
+			t.Fatalf("JSONSchema failed: %v", err)
+		}
+
+		tests := []struct {
+			name string
+			want string
+		}{
+			{"owner", `{"type":"string"}`},
+			{"reviewer", `{"type":["string","null"]}`},
+			{"urgency", `{"default":"High","enum":["Low","Medium","High"],"type":"string"}`},
+			{"labels", `{"default":["bug","ui"],"items":{"type":"string"},"type":"array"}`},
+		}
+		for _, tt := range tests {
+			got := schemaProperty(t, schema, tt.name)
+			if strict && tt.name != "owner" && tt.name != "reviewer" {
+				// Strict schemas make every optional field nullable
+				continue
+			}
+			if got != tt.want {
+				t.Errorf("strict=%v %s: got %s, want %s", strict, tt.name, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestJSONSchemaErrors(t *testing.T) {
+	type badChan struct {
+		Events chan int `json:"events"`
+	}
+	type badFormat struct {
+		Phone string `json:"phone" gsap:"format=phone"`
+	}
+	type badMin struct {
+		Age []int `json:"age" gsap:"min=old"`
+	}
+
+	if _, err := JSONSchema[badChan](); err == nil || !strings.Contains(err.Error(), "events") {
+		t.Errorf("expected error for chan field, got %v", err)
+	}
+	if _, err := JSONSchema[badFormat](); err == nil || !strings.Contains(err.Error(), `unknown format "phone"`) {
+		t.Errorf("expected error for unknown format, got %v", err)
+	}
+	if _, err := JSONSchema[badMin](); err == nil || !strings.Contains(err.Error(), "invalid min") {
+		t.Errorf("expected error for invalid min, got %v", err)
+	}
+}
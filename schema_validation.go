@@ -0,0 +1,817 @@
+package sap
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// schemaNode is a compiled JSON Schema. Keywords other than those listed
+// here are annotations and are ignored.
+type schemaNode struct {
+	boolean *bool // set for the schemas true and false
+
+	types    []string
+	enum     []interface{}
+	constVal interface{}
+	hasConst bool
+
+	properties  map[string]*schemaNode
+	required    []string
+	additional  *schemaNode // nil allows any other property
+	minProps    *int
+	maxProps    *int
+	items       *schemaNode
+	prefixItems []*schemaNode
+	minItems    *int
+	maxItems    *int
+	uniqueItems bool
+
+	minLength *int
+	maxLength *int
+	pattern   *regexp.Regexp
+	format    string
+
+	minimum          *float64
+	maximum          *float64
+	exclusiveMinimum *float64
+	exclusiveMaximum *float64
+	multipleOf       *float64
+
+	allOf []*schemaNode
+	anyOf []*schemaNode
+	oneOf []*schemaNode
+	not   *schemaNode
+	ref   *schemaNode
+}
+
+// schemaCompiler compiles a JSON Schema document. Nodes are cached by
+// JSON pointer, so recursive $refs compile to cyclic nodes.
+type schemaCompiler struct {
+	root  interface{}
+	nodes map[string]*schemaNode
+}
+
+// compileSchema compiles the JSON Schema document in data.
+func compileSchema(data []byte) (*schemaNode, error) {
+	var root interface{}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	return compileSchemaValue(root)
+}
+
+// compileSchemaValue compiles a JSON Schema document decoded from JSON.
+func compileSchemaValue(root interface{}) (*schemaNode, error) {
+	sc := &schemaCompiler{root: root, nodes: make(map[string]*schemaNode)}
+	node, err := sc.compile(root, "#")
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	return node, nil
+}
+
+// compile compiles the schema raw found at pointer.
+func (sc *schemaCompiler) compile(raw interface{}, pointer string) (*schemaNode, error) {
+	if node, ok := sc.nodes[pointer]; ok {
+		return node, nil
+	}
+	node := &schemaNode{}
+	sc.nodes[pointer] = node
+
+	if b, ok := raw.(bool); ok {
+		node.boolean = &b
+		return node, nil
+	}
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: schema must be an object or boolean, got %T", pointer, raw)
+	}
+
+	var err error
+	sub := func(key string) (*schemaNode, error) {
+		v, ok := m[key]
+		if !ok {
+			return nil, nil
+		}
+		return sc.compile(v, pointer+"/"+escapePointer(key))
+	}
+	list := func(key string) ([]*schemaNode, error) {
+		v, ok := m[key]
+		if !ok {
+			return nil, nil
+		}
+		arr, ok := v.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s/%s must be an array", pointer, key)
+		}
+		nodes := make([]*schemaNode, len(arr))
+		for i, s := range arr {
+			if nodes[i], err = sc.compile(s, pointer+"/"+key+"/"+strconv.Itoa(i)); err != nil {
+				return nil, err
+			}
+		}
+		return nodes, nil
+	}
+	count := func(key string) (*int, error) {
+		f, err := schemaNumber(m, key, pointer)
+		if f == nil || err != nil {
+			return nil, err
+		}
+		if *f < 0 || *f != math.Trunc(*f) {
+			return nil, fmt.Errorf("%s/%s must be a non-negative integer", pointer, key)
+		}
+		n := int(*f)
+		return &n, nil
+	}
+
+	switch t := m["type"].(type) {
+	case nil:
+	case string:
+		node.types = []string{t}
+	case []interface{}:
+		for _, v := range t {
+			s, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s/type must list strings", pointer)
+			}
+			node.types = append(node.types, s)
+		}
+	default:
+		return nil, fmt.Errorf("%s/type must be a string or array", pointer)
+	}
+	for _, t := range node.types {
+		switch t {
+		case "object", "array", "string", "number", "integer", "boolean", "null":
+		default:
+			return nil, fmt.Errorf("%s/type: unknown type %q", pointer, t)
+		}
+	}
+
+	if v, ok := m["enum"]; ok {
+		if node.enum, ok = v.([]interface{}); !ok {
+			return nil, fmt.Errorf("%s/enum must be an array", pointer)
+		}
+	}
+	node.constVal, node.hasConst = m["const"]
+
+	if v, ok := m["properties"]; ok {
+		props, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s/properties must be an object", pointer)
+		}
+		node.properties = make(map[string]*schemaNode, len(props))
+		for name, s := range props {
+			if node.properties[name], err = sc.compile(s, pointer+"/properties/"+escapePointer(name)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if v, ok := m["required"]; ok {
+		arr, ok := v.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s/required must be an array", pointer)
+		}
+		for _, name := range arr {
+			s, ok := name.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s/required must list strings", pointer)
+			}
+			node.required = append(node.required, s)
+		}
+	}
+	if node.additional, err = sub("additionalProperties"); err != nil {
+		return nil, err
+	}
+	if node.minProps, err = count("minProperties"); err != nil {
+		return nil, err
+	}
+	if node.maxProps, err = count("maxProperties"); err != nil {
+		return nil, err
+	}
+
+	if node.items, err = sub("items"); err != nil {
+		return nil, err
+	}
+	if node.prefixItems, err = list("prefixItems"); err != nil {
+		return nil, err
+	}
+	if node.minItems, err = count("minItems"); err != nil {
+		return nil, err
+	}
+	if node.maxItems, err = count("maxItems"); err != nil {
+		return nil, err
+	}
+	node.uniqueItems, _ = m["uniqueItems"].(bool)
+
+	if node.minLength, err = count("minLength"); err != nil {
+		return nil, err
+	}
+	if node.maxLength, err = count("maxLength"); err != nil {
+		return nil, err
+	}
+	if v, ok := m["pattern"]; ok {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s/pattern must be a string", pointer)
+		}
+		if node.pattern, err = regexp.Compile(s); err != nil {
+			return nil, fmt.Errorf("%s/pattern: %w", pointer, err)
+		}
+	}
+	node.format, _ = m["format"].(string)
+
+	for key, dst := range map[string]**float64{
+		"minimum":          &node.minimum,
+		"maximum":          &node.maximum,
+		"exclusiveMinimum": &node.exclusiveMinimum,
+		"exclusiveMaximum": &node.exclusiveMaximum,
+		"multipleOf":       &node.multipleOf,
+	} {
+		if *dst, err = schemaNumber(m, key, pointer); err != nil {
+			return nil, err
+		}
+	}
+	if node.multipleOf != nil && *node.multipleOf <= 0 {
+		return nil, fmt.Errorf("%s/multipleOf must be positive", pointer)
+	}
+
+	if node.allOf, err = list("allOf"); err != nil {
+		return nil, err
+	}
+	if node.anyOf, err = list("anyOf"); err != nil {
+		return nil, err
+	}
+	if node.oneOf, err = list("oneOf"); err != nil {
+		return nil, err
+	}
+	if node.not, err = sub("not"); err != nil {
+		return nil, err
+	}
+
+	if v, ok := m["$ref"]; ok {
+		ref, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s/$ref must be a string", pointer)
+		}
+		if node.ref, err = sc.resolve(ref); err != nil {
+			return nil, fmt.Errorf("%s/$ref: %w", pointer, err)
+		}
+	}
+	return node, nil
+}
+
+// resolve compiles the schema a $ref points to.
+func (sc *schemaCompiler) resolve(ref string) (*schemaNode, error) {
+	if node, ok := sc.nodes[ref]; ok {
+		return node, nil
+	}
+	raw, err := sc.lookup(ref)
+	if err != nil {
+		return nil, err
+	}
+	return sc.compile(raw, ref)
+}
+
+// lookup returns the part of the document a $ref points to. Only
+// references within the document, such as "#/$defs/Address", are
+// supported.
+func (sc *schemaCompiler) lookup(ref string) (interface{}, error) {
+	if ref != "#" && !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported reference %q: only references within the document are supported", ref)
+	}
+	raw := sc.root
+	for _, token := range strings.Split(ref, "/")[1:] {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch v := raw.(type) {
+		case map[string]interface{}:
+			next, ok := v[token]
+			if !ok {
+				return nil, fmt.Errorf("reference %q not found", ref)
+			}
+			raw = next
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, fmt.Errorf("reference %q not found", ref)
+			}
+			raw = v[i]
+		default:
+			return nil, fmt.Errorf("reference %q not found", ref)
+		}
+	}
+	return raw, nil
+}
+
+// escapePointer escapes a key for use in a JSON pointer.
+func escapePointer(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
+
+// schemaNumber returns the number m[key], or nil if it is absent.
+func schemaNumber(m map[string]interface{}, key, pointer string) (*float64, error) {
+	v, ok := m[key]
+	if !ok {
+		return nil, nil
+	}
+	f, ok := v.(float64)
+	if !ok {
+		return nil, fmt.Errorf("%s/%s must be a number", pointer, key)
+	}
+	return &f, nil
+}
+
+// ValidateAgainstSchema checks value against the JSON Schema document in
+// schemaJSON, such as one written by another team or generated by
+// JSONSchema:
+//
+//	order, err := sap.Parse[Order](input)
+//	if err == nil {
+//	    err = sap.ValidateAgainstSchema(order, orderSchema)
+//	}
+//
+// value is checked as Parse reads it: struct fields by the names Parse
+// matches, enums by name, and nil slices and maps as empty ones; types
+// that marshal themselves, such as time.Time, as they marshal. Every
+// field is present, since a value on its own doesn't record which fields
+// the input left out; WithJSONSchema does, so it also checks the
+// schema's required keyword. Violations are returned in a *ParseError
+// with one FieldError per violation, at paths like "items[2].sku" and
+// matching ErrSchema. An error that isn't a *ParseError means the schema itself is
+// invalid or value can't be represented in JSON.
+//
+// The type, enum, const, object, array, string, number and combining
+// keywords are checked, as are references within the document. The
+// formats email, uuid, uri, date and date-time are checked; other formats
+// and keywords are ignored.
+func ValidateAgainstSchema(value interface{}, schemaJSON []byte) error {
+	schema, err := compileSchema(schemaJSON)
+	if err != nil {
+		return err
+	}
+	raw, err := schemaValue(reflect.ValueOf(value), "", "", nil)
+	if err != nil {
+		return err
+	}
+	if errs := schema.validate(raw, ""); len(errs) > 0 {
+		return &ParseError{Errors: errs}
+	}
+	return nil
+}
+
+// WithJSONSchema checks each parse result against the JSON Schema
+// document in schemaJSON, as ValidateAgainstSchema does. Violations are
+// reported in the parse's *ParseError alongside any coercion failures and
+// count against the candidate when choosing between several JSON blocks.
+// If the schema is invalid, every parse fails with that error.
+func WithJSONSchema(schemaJSON []byte) Option {
+	schema, err := compileSchema(schemaJSON)
+	return func(p *Parser) {
+		p.schema, p.schemaErr = schema, err
+	}
+}
+
+// schemaValue converts v, found at path, to the form encoding/json decodes
+// JSON to, as Parse reads it: structs as objects keyed by the names Parse
+// matches, enums by name, the variants of discriminated unions with their
+// tag, and nil slices and maps as empty ones. Types that marshal
+// themselves, such as time.Time, are marshaled.
+//
+// presence, from a parse of v, leaves out the struct fields the input
+// didn't have and writes the ones it had as null or a null string as null,
+// so that the schema sees what the model sent. With nil presence every
+// field is written as it is.
+func schemaValue(v reflect.Value, discriminator, path string, presence map[string]FieldPresence) (interface{}, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+	if isMarshaler(v.Type()) {
+		if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+			return nil, nil
+		}
+		return toJSONValue(v.Interface())
+	}
+	if v.CanAddr() && isMarshaler(v.Addr().Type()) {
+		return toJSONValue(v.Addr().Interface())
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return schemaValue(v.Elem(), discriminator, path, presence)
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil, nil
+		}
+		out, err := schemaValue(v.Elem(), "", path, presence)
+		if err != nil {
+			return nil, err
+		}
+		if def := lookupUnion(v.Type()); def != nil {
+			if discriminator != "" {
+				def = def.withDiscriminator(discriminator)
+			}
+			obj, ok := out.(map[string]interface{})
+			if ok && def.discriminator != "" {
+				for i, variant := range def.variants {
+					if variant == v.Elem().Type() {
+						obj[def.discriminator] = def.tagNames()[i]
+					}
+				}
+			}
+		}
+		return out, nil
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if name, ok := intEnumName(v); ok {
+			return name, nil
+		}
+		return float64(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if name, ok := intEnumName(v); ok {
+			return name, nil
+		}
+		return float64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.Slice, reflect.Array:
+		out := make([]interface{}, v.Len())
+		for i := range out {
+			elem, err := schemaValue(v.Index(i), discriminator, path+"["+strconv.Itoa(i)+"]", presence)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = elem
+		}
+		return out, nil
+	case reflect.Map:
+		out := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			key := fmt.Sprint(iter.Key().Interface())
+			elem, err := schemaValue(iter.Value(), "", joinPath(path, key), presence)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = elem
+		}
+		return out, nil
+	case reflect.Struct:
+		plan := structPlanFor(v.Type())
+		out := make(map[string]interface{}, len(plan.fields))
+		for i := range plan.fields {
+			fp := &plan.fields[i]
+			if fp.opts.skip {
+				continue
+			}
+			fieldPath := joinPath(path, fp.pathName)
+			state, parsed := presence[fieldPath]
+			field, err := v.FieldByIndexErr(fp.index)
+			if err != nil || (fp.opts.omitempty && field.IsZero()) || (parsed && state == FieldMissing) {
+				continue
+			}
+			if parsed && (state == FieldNull || state == FieldNullString) {
+				out[fp.pathName] = nil
+				continue
+			}
+			elem, err := schemaValue(field, fp.opts.discriminator, fieldPath, presence)
+			if err != nil {
+				return nil, err
+			}
+			out[fp.pathName] = elem
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("cannot validate %v against a schema", v.Type())
+}
+
+// isMarshaler reports whether t implements json.Marshaler or
+// encoding.TextMarshaler.
+func isMarshaler(t reflect.Type) bool {
+	return t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType)
+}
+
+// intEnumName returns the name of v if its type is an integer enum.
+func intEnumName(v reflect.Value) (string, bool) {
+	def := lookupEnum(v.Type())
+	if def == nil {
+		return "", false
+	}
+	for i, value := range def.values {
+		if value.Equal(v) {
+			return def.names[i], true
+		}
+	}
+	return "", false
+}
+
+// toJSONValue converts v to the generic form encoding/json decodes to.
+func toJSONValue(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("cannot validate %T: %w", v, err)
+	}
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("cannot validate %T: %w", v, err)
+	}
+	return raw, nil
+}
+
+// checkSchema checks a coerced candidate, whose fields got their values as
+// presence records, against the parser's schema. The violations at or
+// below paths that already failed are left out, since the failed values
+// were never set. Each violation adds the SchemaViolated penalty to score.
+func (p *Parser) checkSchema(result interface{}, presence map[string]FieldPresence, failed *ParseError, score *Score) (*ParseError, error) {
+	raw, err := schemaValue(reflect.ValueOf(result), "", "", presence)
+	if err != nil {
+		return failed, err
+	}
+	var errs []FieldError
+	if failed != nil {
+		errs = failed.Errors
+	}
+	n := len(errs)
+	for _, fe := range p.schema.validate(raw, "") {
+		if !coveredPath(errs[:n], fe.Path) {
+			errs = append(errs, fe)
+			score.AddFlag(FlagSchemaViolated, 5)
+		}
+	}
+	if len(errs) == 0 {
+		return nil, nil
+	}
+	return &ParseError{Errors: errs}, nil
+}
+
+// coveredPath reports whether path is at or below the path of one of errs.
+func coveredPath(errs []FieldError, path string) bool {
+	for _, fe := range errs {
+		if fe.Path == "" || path == fe.Path ||
+			strings.HasPrefix(path, fe.Path+".") || strings.HasPrefix(path, fe.Path+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+// validate checks v, decoded from JSON, against the schema and returns a
+// FieldError for each violation.
+func (s *schemaNode) validate(v interface{}, path string) []FieldError {
+	if s.boolean != nil {
+		if *s.boolean {
+			return nil
+		}
+		return []FieldError{schemaViolation(v, path, "no value is allowed here")}
+	}
+
+	var errs []FieldError
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, schemaViolation(v, path, format, args...))
+	}
+
+	if s.ref != nil {
+		errs = append(errs, s.ref.validate(v, path)...)
+	}
+	if len(s.types) > 0 && !matchesType(v, s.types) {
+		fail("expected %s, got %s", strings.Join(s.types, " or "), jsonTypeName(v))
+		return errs
+	}
+	if s.enum != nil && !containsJSON(s.enum, v) {
+		fail("%s is not one of %s", jsonText(v), jsonText(s.enum))
+	}
+	if s.hasConst && !reflect.DeepEqual(v, s.constVal) {
+		fail("%s is not %s", jsonText(v), jsonText(s.constVal))
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		errs = append(errs, s.validateObject(v, path)...)
+	case []interface{}:
+		errs = append(errs, s.validateArray(v, path)...)
+	case string:
+		n := utf8.RuneCountInString(v)
+		if s.minLength != nil && n < *s.minLength {
+			fail("length %d is less than min length %d", n, *s.minLength)
+		}
+		if s.maxLength != nil && n > *s.maxLength {
+			fail("length %d is greater than max length %d", n, *s.maxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			fail("%q does not match pattern %s", v, s.pattern)
+		}
+		format := s.format
+		if format == "uri" {
+			format = "url"
+		}
+		if valid, ok := stringFormats[format]; ok && !valid(v) {
+			fail("%q is not a valid %s", v, s.format)
+		}
+	case float64:
+		switch {
+		case s.minimum != nil && v < *s.minimum:
+			fail("%s is less than min %s", formatFloat(v), formatFloat(*s.minimum))
+		case s.exclusiveMinimum != nil && v <= *s.exclusiveMinimum:
+			fail("%s is not greater than %s", formatFloat(v), formatFloat(*s.exclusiveMinimum))
+		}
+		switch {
+		case s.maximum != nil && v > *s.maximum:
+			fail("%s is greater than max %s", formatFloat(v), formatFloat(*s.maximum))
+		case s.exclusiveMaximum != nil && v >= *s.exclusiveMaximum:
+			fail("%s is not less than %s", formatFloat(v), formatFloat(*s.exclusiveMaximum))
+		}
+		if s.multipleOf != nil {
+			if q := v / *s.multipleOf; math.Abs(q-math.Round(q)) > 1e-9 {
+				fail("%s is not a multiple of %s", formatFloat(v), formatFloat(*s.multipleOf))
+			}
+		}
+	}
+
+	for _, sub := range s.allOf {
+		errs = append(errs, sub.validate(v, path)...)
+	}
+	if len(s.anyOf) > 0 && countMatches(s.anyOf, v, path) == 0 {
+		fail("does not match any of the %d allowed schemas", len(s.anyOf))
+	}
+	if len(s.oneOf) > 0 {
+		if n := countMatches(s.oneOf, v, path); n != 1 {
+			fail("matches %d of the oneOf schemas, want exactly 1", n)
+		}
+	}
+	if s.not != nil && len(s.not.validate(v, path)) == 0 {
+		fail("matches a schema it must not match")
+	}
+	return errs
+}
+
+// validateObject checks the properties of an object.
+func (s *schemaNode) validateObject(v map[string]interface{}, path string) []FieldError {
+	var errs []FieldError
+	for _, name := range s.required {
+		if _, ok := v[name]; !ok {
+			errs = append(errs, FieldError{
+				Path:  joinPath(path, name),
+				Cause: fmt.Errorf("%w: %w", ErrSchema, ErrMissingField),
+			})
+		}
+	}
+	if s.minProps != nil && len(v) < *s.minProps {
+		errs = append(errs, schemaViolation(v, path, "has %d properties, fewer than %d", len(v), *s.minProps))
+	}
+	if s.maxProps != nil && len(v) > *s.maxProps {
+		errs = append(errs, schemaViolation(v, path, "has %d properties, more than %d", len(v), *s.maxProps))
+	}
+
+	// Sorted, so the errors come out in the same order every time
+	names := make([]string, 0, len(v))
+	for name := range v {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if prop, ok := s.properties[name]; ok {
+			errs = append(errs, prop.validate(v[name], joinPath(path, name))...)
+		} else if s.additional != nil {
+			if s.additional.boolean != nil && !*s.additional.boolean {
+				errs = append(errs, schemaViolation(v[name], joinPath(path, name), "property is not allowed"))
+				continue
+			}
+			errs = append(errs, s.additional.validate(v[name], joinPath(path, name))...)
+		}
+	}
+	return errs
+}
+
+// validateArray checks the items of an array.
+func (s *schemaNode) validateArray(v []interface{}, path string) []FieldError {
+	var errs []FieldError
+	if s.minItems != nil && len(v) < *s.minItems {
+		errs = append(errs, schemaViolation(v, path, "has %d items, fewer than %d", len(v), *s.minItems))
+	}
+	if s.maxItems != nil && len(v) > *s.maxItems {
+		errs = append(errs, schemaViolation(v, path, "has %d items, more than %d", len(v), *s.maxItems))
+	}
+	for i, item := range v {
+		itemPath := path + "[" + strconv.Itoa(i) + "]"
+		switch {
+		case i < len(s.prefixItems):
+			errs = append(errs, s.prefixItems[i].validate(item, itemPath)...)
+		case s.items != nil:
+			errs = append(errs, s.items.validate(item, itemPath)...)
+		}
+	}
+	if s.uniqueItems {
+		for i := 1; i < len(v); i++ {
+			if containsJSON(v[:i], v[i]) {
+				errs = append(errs, schemaViolation(v[i], path+"["+strconv.Itoa(i)+"]", "duplicates an earlier item"))
+			}
+		}
+	}
+	return errs
+}
+
+// countMatches returns how many of schemas v matches.
+func countMatches(schemas []*schemaNode, v interface{}, path string) int {
+	n := 0
+	for _, s := range schemas {
+		if len(s.validate(v, path)) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// matchesType reports whether v has one of the JSON types.
+func matchesType(v interface{}, types []string) bool {
+	for _, t := range types {
+		switch v := v.(type) {
+		case nil:
+			if t == "null" {
+				return true
+			}
+		case bool:
+			if t == "boolean" {
+				return true
+			}
+		case string:
+			if t == "string" {
+				return true
+			}
+		case float64:
+			if t == "number" || (t == "integer" && v == math.Trunc(v)) {
+				return true
+			}
+		case []interface{}:
+			if t == "array" {
+				return true
+			}
+		case map[string]interface{}:
+			if t == "object" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// jsonTypeName returns the JSON type of v.
+func jsonTypeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case []interface{}:
+		return "array"
+	}
+	return "object"
+}
+
+// containsJSON reports whether values holds a value equal to v.
+func containsJSON(values []interface{}, v interface{}) bool {
+	for _, e := range values {
+		if reflect.DeepEqual(e, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonText renders v as JSON for an error message.
+func jsonText(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// schemaViolation returns a FieldError matching ErrSchema.
+func schemaViolation(v interface{}, path, format string, args ...interface{}) FieldError {
+	return FieldError{
+		Path:  path,
+		Raw:   v,
+		Cause: fmt.Errorf("%w: %s", ErrSchema, fmt.Sprintf(format, args...)),
+	}
+}
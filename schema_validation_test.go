@@ -0,0 +1,247 @@
+package sap
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// schemaErrors returns the errors of a *ParseError as "path: message".
+func schemaErrors(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected *ParseError, got %v", err)
+	}
+	out := make([]string, len(perr.Errors))
+	for i, fe := range perr.Errors {
+		if !errors.Is(fe.Cause, ErrSchema) {
+			t.Errorf("error %v does not match ErrSchema", fe)
+		}
+		out[i] = fe.Error()
+	}
+	return out
+}
+
+const testOrderSchema = `{
+	"type": "object",
+	"properties": {
+		"id": {"type": "string", "pattern": "^A-[0-9]+$"},
+		"status": {"enum": ["open", "closed"]},
+		"email": {"type": "string", "format": "email"},
+		"total": {"type": "number", "minimum": 0, "exclusiveMaximum": 1000},
+		"items": {
+			"type": "array",
+			"minItems": 1,
+			"items": {"$ref": "#/$defs/item"}
+		},
+		"notes": {"type": ["string", "null"], "maxLength": 5}
+	},
+	"required": ["id", "items"],
+	"additionalProperties": false,
+	"$defs": {
+		"item": {
+			"type": "object",
+			"properties": {
+				"sku": {"type": "string", "minLength": 1},
+				"qty": {"type": "integer", "multipleOf": 1, "maximum": 10}
+			},
+			"required": ["sku"]
+		}
+	}
+}`
+
+func TestValidateAgainstSchema(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  []string
+	}{
+		{
+			name:  "valid",
+			value: `{"id": "A-1", "status": "open", "email": "a@b.co", "total": 10, "items": [{"sku": "x", "qty": 2}], "notes": null}`,
+		},
+		{
+			name:  "missing required",
+			value: `{"items": [{"qty": 1}]}`,
+			want:  []string{"id: schema violated: missing required field", "items[0].sku: schema violated: missing required field"},
+		},
+		{
+			name:  "wrong types",
+			value: `{"id": 7, "items": "none", "notes": false}`,
+			want: []string{
+				"id: schema violated: expected string, got number",
+				"items: schema violated: expected array, got string",
+				"notes: schema violated: expected string or null, got boolean",
+			},
+		},
+		{
+			name:  "values",
+			value: `{"id": "B-1", "status": "lost", "email": "nobody", "total": 1000, "items": [{"sku": "", "qty": 2.5}], "notes": "too long"}`,
+			want: []string{
+				`email: schema violated: "nobody" is not a valid email`,
+				`id: schema violated: "B-1" does not match pattern ^A-[0-9]+$`,
+				"items[0].qty: schema violated: expected integer, got number",
+				"items[0].sku: schema violated: length 0 is less than min length 1",
+				"notes: schema violated: length 8 is greater than max length 5",
+				`status: schema violated: "lost" is not one of ["open","closed"]`,
+				"total: schema violated: 1000 is not less than 1000",
+			},
+		},
+		{
+			name:  "array and extra properties",
+			value: `{"id": "A-1", "items": [], "extra": 1}`,
+			want:  []string{"extra: schema violated: property is not allowed", "items: schema violated: has 0 items, fewer than 1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var value interface{}
+			if err := json.Unmarshal([]byte(tt.value), &value); err != nil {
+				t.Fatal(err)
+			}
+			got := schemaErrors(t, ValidateAgainstSchema(value, []byte(testOrderSchema)))
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("got errors\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestValidateAgainstSchemaCombinators(t *testing.T) {
+	schema := []byte(`{
+		"$defs": {"node": {"type": "object", "properties": {"next": {"anyOf": [{"$ref": "#/$defs/node"}, {"type": "null"}]}}, "required": ["next"]}},
+		"properties": {
+			"shape": {"oneOf": [{"type": "integer"}, {"type": "number", "minimum": 5}]},
+			"kind": {"allOf": [{"type": "string"}, {"not": {"const": "none"}}]},
+			"list": {"$ref": "#/$defs/node"},
+			"tags": {"type": "array", "uniqueItems": true}
+		}
+	}`)
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{`{"shape": 3, "kind": "a", "list": {"next": {"next": null}}, "tags": [1, 2]}`, ""},
+		{`{"shape": 7}`, "shape: schema violated: matches 2 of the oneOf schemas, want exactly 1"},
+		{`{"shape": 3, "kind": "none"}`, "kind: schema violated: matches a schema it must not match"},
+		{`{"list": {"next": {"next": 1}}}`, "list.next: schema violated: does not match any of the 2 allowed schemas"},
+		{`{"list": {"next": {}}}`, "list.next: schema violated: does not match any of the 2 allowed schemas"},
+		{`{"tags": ["a", "b", "a"]}`, "tags[2]: schema violated: duplicates an earlier item"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			var value interface{}
+			if err := json.Unmarshal([]byte(tt.value), &value); err != nil {
+				t.Fatal(err)
+			}
+			got := strings.Join(schemaErrors(t, ValidateAgainstSchema(value, schema)), "\n")
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateAgainstSchemaInvalid(t *testing.T) {
+	tests := []struct {
+		schema string
+		want   string
+	}{
+		{`{"type": "text"}`, `unknown type "text"`},
+		{`{"properties": {"a": {"pattern": "("}}}`, "#/properties/a/pattern"},
+		{`{"items": {"$ref": "#/$defs/missing"}}`, `reference "#/$defs/missing" not found`},
+		{`{"$ref": "https://example.com/schema.json"}`, "unsupported reference"},
+		{`{"minLength": -1}`, "must be a non-negative integer"},
+		{`[1]`, "schema must be an object or boolean"},
+	}
+	for _, tt := range tests {
+		err := ValidateAgainstSchema("x", []byte(tt.schema))
+		var perr *ParseError
+		if err == nil || errors.As(err, &perr) || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("schema %s: expected error containing %q, got %v", tt.schema, tt.want, err)
+		}
+	}
+}
+
+// TestValidateAgainstGeneratedSchema checks that parse results validate
+// against the schema JSONSchema generates for their type.
+func TestValidateAgainstGeneratedSchema(t *testing.T) {
+	schema, err := JSONSchema[testSchemaOrder]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	order, err := Parse[testSchemaOrder](`{id: "A-1", status: "closed", quantity: 2, email: "a@b.co", codes: ["AB"], placed: "2024-01-02T03:04:05Z", priority: "low", mood: "neutral"}`)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if err := ValidateAgainstSchema(order, data); err != nil {
+		t.Errorf("expected valid order, got %v", err)
+	}
+
+	order.Quantity = 100
+	got := schemaErrors(t, ValidateAgainstSchema(order, data))
+	if len(got) != 1 || got[0] != "quantity: schema violated: 100 is greater than max 99" {
+		t.Errorf("unexpected errors %q", got)
+	}
+}
+
+func TestWithJSONSchema(t *testing.T) {
+	schema := []byte(`{
+		"type": "object",
+		"properties": {
+			"name": {"type": "string", "minLength": 2},
+			"age": {"type": "integer", "minimum": 0, "maximum": 150}
+		},
+		"required": ["name"]
+	}`)
+	opt := WithJSONSchema(schema)
+
+	user, score, err := ParseWithScore[TestUser](`{"name": "Al", "age": "30"}`, opt)
+	if err != nil || user.Age != 30 || score.Flags()[string(FlagSchemaViolated)] != 0 {
+		t.Errorf("expected valid parse, got %+v, %v, %v", user, score.Flags(), err)
+	}
+
+	user, score, err = ParseWithScore[TestUser](`{"name": "A", "age": 200}`, opt)
+	got := schemaErrors(t, err)
+	if strings.Join(got, "\n") != "age: schema violated: 200 is greater than max 150\nname: schema violated: length 1 is less than min length 2" {
+		t.Errorf("unexpected errors %q", got)
+	}
+	if user.Name != "A" || score.Total() < 10 || score.Flags()[string(FlagSchemaViolated)] == 0 {
+		t.Errorf("expected partial result and penalty, got %+v, %v", user, score.Flags())
+	}
+
+	// The candidate that satisfies the schema wins
+	user, err = Parse[TestUser](`First try: {"name": "Bob", "age": -1}. Fixed: {"name": "Bob", "age": 41}`, opt)
+	if err != nil || user.Age != 41 {
+		t.Errorf("expected valid candidate, got %+v, %v", user, err)
+	}
+
+	// Fields the input left out are left out of the checked value, and
+	// null strings are checked as null
+	_, err = Parse[TestUser](`{"age": "N/A"}`, opt)
+	got = schemaErrors(t, err)
+	if strings.Join(got, "\n") != "name: schema violated: missing required field\nage: schema violated: expected integer, got null" {
+		t.Errorf("unexpected errors %q", got)
+	}
+
+	// Failed fields are reported once, by the coercer
+	_, err = Parse[TestUser](`{"name": "Al", "age": "old"}`, opt)
+	var perr *ParseError
+	if !errors.As(err, &perr) || len(perr.Errors) != 1 || errors.Is(err, ErrSchema) {
+		t.Errorf("expected only the coercion failure, got %v", err)
+	}
+
+	if _, err := Parse[TestUser](`{"name": "Al"}`, WithJSONSchema([]byte(`{"type": 1}`))); err == nil || !strings.Contains(err.Error(), "invalid schema") {
+		t.Errorf("expected invalid schema error, got %v", err)
+	}
+}
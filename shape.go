@@ -0,0 +1,107 @@
+package sap
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// shapeKind is how values of a Go type appear in the JSON that Parse
+// accepts for it.
+type shapeKind int
+
+const (
+	shapeTime    shapeKind = iota // time.Time, an RFC 3339 string
+	shapeText                     // decodes itself from a string, e.g. uuid.UUID
+	shapeRaw                      // decodes itself from any JSON
+	shapePointer                  // the element's shape, or null
+	shapeUnion                    // any variant of a registered union
+	shapeAny                      // any JSON, for interfaces that aren't unions
+	shapeEnum                     // one of a fixed set of names
+	shapeString
+	shapeInt
+	shapeUint
+	shapeFloat
+	shapeBool
+	shapeList // an array, for slices and arrays
+	shapeMap  // an object with arbitrary keys
+	shapeStruct
+)
+
+// typeShape is the JSON shape of a type in a field with given options.
+type typeShape struct {
+	kind  shapeKind
+	enum  *enumDef  // for shapeEnum, including the field's enum and aliases
+	union *unionDef // for shapeUnion, with the field's discriminator
+}
+
+// shapeOf classifies type t in a field with the given options the way
+// coercion does: time.Time and types that decode themselves first, then
+// the kind, with enums and unions picked out of strings, integers and
+// interfaces. JSONSchema, Grammar, RenderFormat and Example all switch on
+// its result, so they agree on what each type accepts.
+//
+// It returns an error if the options are malformed or t can't be parsed
+// from JSON, such as a channel or func.
+func shapeOf(t reflect.Type, opts fieldOptions) (typeShape, error) {
+	if opts.err != nil {
+		return typeShape{}, opts.err
+	}
+
+	switch {
+	case t == timeType:
+		return typeShape{kind: shapeTime}, nil
+	case isUnmarshaler(t):
+		if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+			return typeShape{kind: shapeText}, nil
+		}
+		return typeShape{kind: shapeRaw}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return typeShape{kind: shapePointer}, nil
+	case reflect.Interface:
+		def := lookupUnion(t)
+		if def == nil {
+			return typeShape{kind: shapeAny}, nil
+		}
+		def = def.withOptions(opts)
+		return typeShape{kind: shapeUnion, union: def}, nil
+	case reflect.String:
+		if def := opts.enumDef(t); def != nil {
+			return typeShape{kind: shapeEnum, enum: def}, nil
+		}
+		return typeShape{kind: shapeString}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if def := opts.enumDef(t); def != nil {
+			return typeShape{kind: shapeEnum, enum: def}, nil
+		}
+		return typeShape{kind: shapeInt}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if def := opts.enumDef(t); def != nil {
+			return typeShape{kind: shapeEnum, enum: def}, nil
+		}
+		return typeShape{kind: shapeUint}, nil
+	case reflect.Float32, reflect.Float64:
+		return typeShape{kind: shapeFloat}, nil
+	case reflect.Bool:
+		return typeShape{kind: shapeBool}, nil
+	case reflect.Slice, reflect.Array:
+		return typeShape{kind: shapeList}, nil
+	case reflect.Map:
+		return typeShape{kind: shapeMap}, nil
+	case reflect.Struct:
+		return typeShape{kind: shapeStruct}, nil
+	}
+	return typeShape{}, fmt.Errorf("%v cannot be parsed from JSON", t)
+}
+
+// describeError reports err, found at path while describing a type, as a
+// failure to do task, e.g. "cannot render format for items[].sku: ...".
+// The root of the type has an empty path.
+func describeError(task, path string, err error) error {
+	if path == "" {
+		return fmt.Errorf("cannot %s: %w", task, err)
+	}
+	return fmt.Errorf("cannot %s for %s: %w", task, path, err)
+}
@@ -0,0 +1,70 @@
+package sap
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShapeOf(t *testing.T) {
+	tests := []struct {
+		name string
+		t    reflect.Type
+		tag  reflect.StructTag
+		want shapeKind
+	}{
+		{"time", reflect.TypeOf(time.Time{}), "", shapeTime},
+		{"text unmarshaler", reflect.TypeOf(testCurrency{}), "", shapeText},
+		{"json unmarshaler", reflect.TypeOf(testMoney{}), "", shapeRaw},
+		{"pointer", reflect.TypeOf((*int)(nil)), "", shapePointer},
+		{"union", reflect.TypeOf((*testShape)(nil)).Elem(), "", shapeUnion},
+		{"interface", reflect.TypeOf((*interface{})(nil)).Elem(), "", shapeAny},
+		{"registered enum", reflect.TypeOf(testSentiment("")), "", shapeEnum},
+		{"tag enum", reflect.TypeOf(""), `gsap:"enum=a|b"`, shapeEnum},
+		{"string", reflect.TypeOf(""), "", shapeString},
+		{"int", reflect.TypeOf(int8(0)), "", shapeInt},
+		{"uint", reflect.TypeOf(uint(0)), "", shapeUint},
+		{"float", reflect.TypeOf(float32(0)), "", shapeFloat},
+		{"bool", reflect.TypeOf(false), "", shapeBool},
+		{"slice", reflect.TypeOf([]string{}), "", shapeList},
+		{"array", reflect.TypeOf([2]int{}), "", shapeList},
+		{"map", reflect.TypeOf(map[string]int{}), "", shapeMap},
+		{"struct", reflect.TypeOf(struct{ A int }{}), "", shapeStruct},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shape, err := shapeOf(tt.t, parseFieldOptions(tt.tag))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if shape.kind != tt.want {
+				t.Errorf("kind = %d, want %d", shape.kind, tt.want)
+			}
+		})
+	}
+
+	shape, _ := shapeOf(reflect.TypeOf((*testShape)(nil)).Elem(), parseFieldOptions(`gsap:"discriminator=kind"`))
+	if shape.union == nil || shape.union.discriminator != "kind" {
+		t.Errorf("expected the field's discriminator on the union, got %+v", shape.union)
+	}
+
+	if _, err := shapeOf(reflect.TypeOf(make(chan int)), fieldOptions{}); err == nil || !strings.Contains(err.Error(), "cannot be parsed from JSON") {
+		t.Errorf("expected an error for a channel, got %v", err)
+	}
+	if _, err := shapeOf(reflect.TypeOf(""), parseFieldOptions(`gsap:"minimum=3"`)); err == nil {
+		t.Error("expected the malformed tag to be reported")
+	}
+}
+
+func TestDescribeError(t *testing.T) {
+	cause := errors.New("boom")
+	err := describeError("render format", "items[].sku", cause)
+	if err.Error() != "cannot render format for items[].sku: boom" || !errors.Is(err, cause) {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := describeError("render format", "", cause); err.Error() != "cannot render format: boom" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,209 @@
+package sap
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// fieldOptions holds the options declared in a field's `gsap` struct tag.
+//
+// The tag is a comma-separated list of options, e.g.
+//
+//	Sentiment string `json:"sentiment" gsap:"enum=positive|negative,alias=thumbs up:positive"`
+//
+// Values containing commas can be wrapped in single quotes, e.g.
+// default='go, rust'. The omitempty and skip options come from the field's
+// `json` tag, the description from its `description` tag, and the example
+// value from its `example` tag.
+type fieldOptions struct {
+	enum          []string            // allowed values from enum=a|b|c
+	tagEnums      *sync.Map           // reflect.Type -> *enumDef built from enum and aliases
+	aliases       map[string][]string // canonical value -> aliases, from alias=x:a|y:b
+	discriminator string              // union tag key from discriminator=type
+	required      bool                // field must be present, from required
+	defaultValue  string              // value used when the field is missing or null, from default=x
+	hasDefault    bool                // default= was given
+	min, max      *float64            // numeric bounds from min=0,max=120
+	minLen        *int                // lower length bound from len=2..10
+	maxLen        *int                // upper length bound; nil is unbounded
+	pattern       string              // regular expression strings must match, from pattern=^[A-Z]+$
+	format        string              // named string format from format=email
+	err           error               // malformed or unknown option
+	omitempty     bool                // json tag has omitempty
+	skip          bool                // json tag is "-"
+	description   string              // what the field means, from the description tag
+	example       string              // sample value for Example, from the example tag
+	hasExample    bool                // the example tag was given
+}
+
+// parseFieldOptions parses the `gsap` struct tag of a field. Malformed and
+// unknown options are recorded in err rather than ignored, so that a typo
+// or an unquoted comma splitting a value can't silently change what the
+// field accepts.
+func parseFieldOptions(tag reflect.StructTag) fieldOptions {
+	var opts fieldOptions
+
+	if jsonTag, ok := tag.Lookup("json"); ok {
+		_, rest, _ := strings.Cut(jsonTag, ",")
+		opts.skip = jsonTag == "-"
+		for _, o := range strings.Split(rest, ",") {
+			if o == "omitempty" {
+				opts.omitempty = true
+			}
+		}
+	}
+
+	opts.description = tag.Get("description")
+	opts.example, opts.hasExample = tag.Lookup("example")
+
+	raw, ok := tag.Lookup("gsap")
+	if !ok {
+		return opts
+	}
+
+	for _, part := range splitTagOptions(raw) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, value, hasValue := strings.Cut(part, "=")
+		value = unquoteTagValue(value)
+		if !hasValue && key != "required" {
+			if knownTagOptions[key] {
+				opts.err = fmt.Errorf("option %q in gsap tag needs a value", key)
+			} else {
+				opts.err = fmt.Errorf("malformed option %q in gsap tag; wrap values containing commas in single quotes", part)
+			}
+			continue
+		}
+		switch key {
+		case "enum":
+			for _, v := range strings.Split(value, "|") {
+				if v = strings.TrimSpace(v); v != "" {
+					opts.enum = append(opts.enum, v)
+				}
+			}
+			opts.tagEnums = new(sync.Map)
+		case "alias":
+			// Each entry maps an alias to the canonical value it stands for
+			for _, entry := range strings.Split(value, "|") {
+				alias, canonical, ok := strings.Cut(entry, ":")
+				alias, canonical = strings.TrimSpace(alias), strings.TrimSpace(canonical)
+				if !ok || alias == "" || canonical == "" {
+					opts.err = fmt.Errorf("invalid alias %q in gsap tag, want alias:value", entry)
+					continue
+				}
+				if opts.aliases == nil {
+					opts.aliases = make(map[string][]string)
+				}
+				opts.aliases[canonical] = append(opts.aliases[canonical], alias)
+			}
+		case "discriminator":
+			opts.discriminator = strings.TrimSpace(value)
+		case "required":
+			opts.required = true
+		case "default":
+			opts.defaultValue, opts.hasDefault = value, true
+		case "min", "max":
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				opts.err = fmt.Errorf("invalid %s %q in gsap tag", key, value)
+				continue
+			}
+			if key == "min" {
+				opts.min = &f
+			} else {
+				opts.max = &f
+			}
+		case "len":
+			if err := opts.parseLen(value); err != nil {
+				opts.err = err
+			}
+		case "pattern":
+			opts.pattern = value
+		case "format":
+			opts.format = value
+		default:
+			opts.err = fmt.Errorf("unknown option %q in gsap tag", key)
+		}
+	}
+
+	return opts
+}
+
+// knownTagOptions are the options a `gsap` tag may contain.
+var knownTagOptions = map[string]bool{
+	"enum": true, "alias": true, "discriminator": true, "required": true, "default": true,
+	"min": true, "max": true, "len": true, "pattern": true, "format": true,
+}
+
+// parseLen parses a length range: "2..10", "2..", "..10", or an exact "5".
+func (o *fieldOptions) parseLen(value string) error {
+	lo, hi, isRange := strings.Cut(value, "..")
+	if !isRange {
+		hi = lo
+	}
+	bound := func(s string) (*int, error) {
+		if s = strings.TrimSpace(s); s == "" && isRange {
+			return nil, nil
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid len %q in gsap tag", value)
+		}
+		return &n, nil
+	}
+	var err error
+	if o.minLen, err = bound(lo); err != nil {
+		return err
+	}
+	o.maxLen, err = bound(hi)
+	return err
+}
+
+// splitTagOptions splits a `gsap` tag on commas outside single quotes.
+func splitTagOptions(raw string) []string {
+	var parts []string
+	quoted := false
+	start := 0
+	for i, r := range raw {
+		switch {
+		case r == '\'':
+			quoted = !quoted
+		case r == ',' && !quoted:
+			parts = append(parts, raw[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, raw[start:])
+}
+
+// unquoteTagValue trims an option value and removes the single quotes
+// around it, which preserve any spaces inside.
+func unquoteTagValue(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// affectsCoercion reports whether the options change how the field's value
+// is coerced.
+func (o fieldOptions) affectsCoercion() bool {
+	return o.hasEnum() || o.discriminator != ""
+}
+
+// hasConstraints reports whether the options restrict the field's value.
+func (o fieldOptions) hasConstraints() bool {
+	return o.min != nil || o.max != nil || o.minLen != nil || o.maxLen != nil ||
+		o.pattern != "" || o.format != "" || o.err != nil
+}
+
+// hasEnum reports whether the options declare enum values or aliases.
+func (o fieldOptions) hasEnum() bool {
+	return len(o.enum) > 0 || len(o.aliases) > 0
+}
@@ -0,0 +1,100 @@
+package sap
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFieldOptionsEnum(t *testing.T) {
+	opts := parseFieldOptions(`json:"status" gsap:"enum= open | closed ||"`)
+	want := []string{"open", "closed"}
+	if !reflect.DeepEqual(opts.enum, want) {
+		t.Errorf("enum = %v, want %v", opts.enum, want)
+	}
+
+	if opts := parseFieldOptions(`json:"status"`); opts.hasEnum() {
+		t.Errorf("expected no enum options, got %+v", opts)
+	}
+}
+
+func TestParseFieldOptionsAlias(t *testing.T) {
+	opts := parseFieldOptions(`gsap:"enum=a|b,alias=x:a|y:a|z:b|bad"`)
+	want := map[string][]string{"a": {"x", "y"}, "b": {"z"}}
+	if !reflect.DeepEqual(opts.aliases, want) {
+		t.Errorf("aliases = %v, want %v", opts.aliases, want)
+	}
+	if opts.err == nil || !strings.Contains(opts.err.Error(), `"bad"`) {
+		t.Errorf("expected error for alias without a value, got %v", opts.err)
+	}
+}
+
+func TestParseFieldOptionsRequired(t *testing.T) {
+	opts := parseFieldOptions(`json:"name,omitempty" gsap:"required"`)
+	if !opts.required || !opts.omitempty || opts.skip {
+		t.Errorf("unexpected options %+v", opts)
+	}
+
+	if opts := parseFieldOptions(`json:"-"`); !opts.skip {
+		t.Errorf("expected json:\"-\" to skip the field, got %+v", opts)
+	}
+	if opts := parseFieldOptions(`json:"-,"`); opts.skip {
+		t.Errorf("expected json:\"-,\" to name the field \"-\", got %+v", opts)
+	}
+}
+
+func TestParseFieldOptionsDefault(t *testing.T) {
+	tests := []struct {
+		tag  reflect.StructTag
+		want string
+	}{
+		{`gsap:"default=medium"`, "medium"},
+		{`gsap:"default= 42 ,required"`, "42"},
+		{`gsap:"default='go, rust',enum=a|b"`, "go, rust"},
+		{`gsap:"default="`, ""},
+	}
+	for _, tt := range tests {
+		opts := parseFieldOptions(tt.tag)
+		if !opts.hasDefault || opts.defaultValue != tt.want {
+			t.Errorf("%s: default = %q (set %v), want %q", tt.tag, opts.defaultValue, opts.hasDefault, tt.want)
+		}
+	}
+
+	if opts := parseFieldOptions(`gsap:"required"`); opts.hasDefault {
+		t.Errorf("expected no default, got %+v", opts)
+	}
+}
+
+func TestParseFieldOptionsErrors(t *testing.T) {
+	tests := []struct {
+		tag     reflect.StructTag
+		wantErr string // "" for none
+	}{
+		{`gsap:"pattern='^[A-Z]{2,3}$',required"`, ""},
+		{`gsap:"required,"`, ""},
+		{`gsap:"pattern=^[A-Z]{2,3}$"`, `malformed option "3}$"`},
+		{`gsap:"requird"`, `malformed option "requird"`},
+		{`gsap:"minimum=3"`, `unknown option "minimum"`},
+		{`gsap:"enum"`, `option "enum" in gsap tag needs a value`},
+	}
+	for _, tt := range tests {
+		opts := parseFieldOptions(tt.tag)
+		switch {
+		case tt.wantErr == "" && opts.err != nil:
+			t.Errorf("%s: unexpected error %v", tt.tag, opts.err)
+		case tt.wantErr != "" && (opts.err == nil || !strings.Contains(opts.err.Error(), tt.wantErr)):
+			t.Errorf("%s: got error %v, want %q", tt.tag, opts.err, tt.wantErr)
+		}
+	}
+
+	if opts := parseFieldOptions(`gsap:"pattern='^[A-Z]{2,3}$'"`); opts.pattern != "^[A-Z]{2,3}$" {
+		t.Errorf("pattern = %q, want ^[A-Z]{2,3}$", opts.pattern)
+	}
+
+	type code struct {
+		Code string `json:"code" gsap:"pattern=^[A-Z]{2,3}$"`
+	}
+	if _, err := Parse[code](`{"code": "ABC"}`); err == nil || !strings.Contains(err.Error(), "single quotes") {
+		t.Errorf("expected the malformed tag to be reported, got %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package sap
 
 import (
+	"errors"
 	"testing"
 	"time"
 )
@@ -152,10 +153,15 @@ func TestParseTimeDatetimeWithSpace(t *testing.T) {
 func TestParseTimeInvalidString(t *testing.T) {
 	input := `{"name": "Bad", "start_time": "not a date"}`
 
-	// Should still parse, but start_time will be zero since coercion fails and is skipped
+	// The partial result is returned, with start_time left at zero and
+	// reported in a ParseError
 	event, err := Parse[TestEvent](input)
-	if err != nil {
-		t.Fatalf("Parse failed: %v", err)
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("Expected *ParseError, got %v", err)
+	}
+	if len(perr.Errors) != 1 || perr.Errors[0].Path != "start_time" {
+		t.Errorf("Expected one error at start_time, got %v", perr.Errors)
 	}
 
 	if event.Name != "Bad" {
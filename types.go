@@ -16,27 +16,81 @@ const (
 	Pending
 )
 
+// RequiredPolicy decides which struct fields must be present in the input.
+type RequiredPolicy int
+
+const (
+	// RequireTagged requires only fields tagged `gsap:"required"`
+	RequireTagged RequiredPolicy = iota
+	// RequireNonOptional also requires every non-pointer field whose json
+	// tag lacks omitempty
+	RequireNonOptional
+)
+
+// ConstraintPolicy decides what happens when a value breaks a constraint
+// such as `gsap:"min=0,max=120"`.
+type ConstraintPolicy int
+
+const (
+	// PenalizeViolations keeps the candidate, leaving the violating field
+	// at its zero value, and reports a FieldError with a score penalty
+	PenalizeViolations ConstraintPolicy = iota
+	// RejectViolations discards the candidate so another one can win
+	RejectViolations
+)
+
+// FieldPresence describes where a struct field's value came from.
+type FieldPresence int
+
+const (
+	// FieldMissing means the input had no value for the field, so it kept
+	// its zero value
+	FieldMissing FieldPresence = iota
+	// FieldPopulated means the field was set from a value in the input
+	FieldPopulated
+	// FieldNull means the input held null, so the field was zeroed
+	FieldNull
+	// FieldDefaulted means the input was missing or null and the field was
+	// set from its `gsap:"default=..."` tag
+	FieldDefaulted
+	// FieldNullString means the input held a null-like string such as
+	// "N/A" or "unknown", so the field was zeroed
+	FieldNullString
+)
+
 // ScoreFlag represents a type of coercion or transformation applied during parsing.
 type ScoreFlag = string
 
 const (
-	FlagFloatToInt          ScoreFlag = "FloatToInt"
-	FlagStringToInt         ScoreFlag = "StringToInt"
-	FlagBoolToInt           ScoreFlag = "BoolToInt"
-	FlagStringToFloat       ScoreFlag = "StringToFloat"
-	FlagStringToBool        ScoreFlag = "StringToBool"
-	FlagNumberToBool        ScoreFlag = "NumberToBool"
-	FlagFuzzyFieldMatch     ScoreFlag = "FuzzyFieldMatch"
-	FlagEnumCaseInsensitive ScoreFlag = "EnumCaseInsensitive"
-	FlagEnumFuzzyMatch      ScoreFlag = "EnumFuzzyMatch"
-	FlagStringToTime        ScoreFlag = "StringToTime"
-	FlagUnixToTime          ScoreFlag = "UnixToTime"
-	FlagMarkdownStripped    ScoreFlag = "MarkdownStripped"
-	FlagUnitStripped        ScoreFlag = "UnitStripped"
-	FlagMultiplierApplied   ScoreFlag = "MultiplierApplied"
-	FlagNullStringCoerced   ScoreFlag = "NullStringCoerced"
-	FlagCommaSplitToSlice   ScoreFlag = "CommaSplitToSlice"
-	FlagEmbeddedStruct      ScoreFlag = "EmbeddedStruct"
+	FlagFloatToInt           ScoreFlag = "FloatToInt"
+	FlagStringToInt          ScoreFlag = "StringToInt"
+	FlagBoolToInt            ScoreFlag = "BoolToInt"
+	FlagStringToFloat        ScoreFlag = "StringToFloat"
+	FlagStringToBool         ScoreFlag = "StringToBool"
+	FlagNumberToBool         ScoreFlag = "NumberToBool"
+	FlagFuzzyFieldMatch      ScoreFlag = "FuzzyFieldMatch"
+	FlagEnumCaseInsensitive  ScoreFlag = "EnumCaseInsensitive"
+	FlagEnumFuzzyMatch       ScoreFlag = "EnumFuzzyMatch"
+	FlagEnumWordMatch        ScoreFlag = "EnumWordMatch"
+	FlagEnumAliasMatch       ScoreFlag = "EnumAliasMatch"
+	FlagEnumDescriptionMatch ScoreFlag = "EnumDescriptionMatch"
+	FlagIntEnumFromName      ScoreFlag = "IntEnumFromName"
+	FlagIntEnumFromNumber    ScoreFlag = "IntEnumFromNumber"
+	FlagStringToTime         ScoreFlag = "StringToTime"
+	FlagUnixToTime           ScoreFlag = "UnixToTime"
+	FlagMarkdownStripped     ScoreFlag = "MarkdownStripped"
+	FlagUnitStripped         ScoreFlag = "UnitStripped"
+	FlagMultiplierApplied    ScoreFlag = "MultiplierApplied"
+	FlagNullStringCoerced    ScoreFlag = "NullStringCoerced"
+	FlagCommaSplitToSlice    ScoreFlag = "CommaSplitToSlice"
+	FlagEmbeddedStruct       ScoreFlag = "EmbeddedStruct"
+	FlagExtractedFromText    ScoreFlag = "ExtractedFromText"
+	FlagUnionUnmatchedKeys   ScoreFlag = "UnionUnmatchedKeys"
+	FlagUnionMissingFields   ScoreFlag = "UnionMissingFields"
+	FlagDefaultApplied       ScoreFlag = "DefaultApplied"
+	FlagConstraintViolated   ScoreFlag = "ConstraintViolated"
+	FlagValidationFailed     ScoreFlag = "ValidationFailed"
+	FlagSchemaViolated       ScoreFlag = "SchemaViolated"
 )
 
 // Score represents the quality of a parse result
@@ -60,6 +114,17 @@ func (s *Score) Total() int {
 	return s.total
 }
 
+// merge adds other's flags and total to s.
+func (s *Score) merge(other *Score) {
+	if s.flags == nil {
+		s.flags = make(map[string]int)
+	}
+	for flag, value := range other.flags {
+		s.flags[flag] = value
+	}
+	s.total += other.total
+}
+
 // Less returns true if this score is better than other
 func (s *Score) Less(other *Score) bool {
 	return s.total < other.total
@@ -79,10 +144,24 @@ func (s *Score) Flags() map[string]int {
 
 // ParseResult represents a successful parse
 type ParseResult struct {
-	Value             interface{}
-	Score             *Score
-	CompletionState   CompletionState
-	RemainingContent  string // Text that wasn't part of JSON
+	Value            interface{}
+	Score            *Score
+	CompletionState  CompletionState
+	RemainingContent string   // Text that wasn't part of JSON
+	Missing          []string // Paths of required fields absent from the input, e.g. "address.city"
+
+	// Presence records how each struct field got its value, keyed by path
+	// like "employees[2].age". Fields that failed to coerce are reported
+	// in the *ParseError instead.
+	Presence map[string]FieldPresence
+}
+
+// Populated reports whether the struct field at path was set from a value
+// in the input, rather than left at its zero value. Use it to merge a
+// result into an existing record without overwriting data the input did
+// not mention.
+func (r *ParseResult) Populated(path string) bool {
+	return r.Presence[path] == FieldPopulated
 }
 
 // JSONCandidate represents a potential JSON string extracted from text
@@ -91,8 +170,10 @@ type JSONCandidate struct {
 	Index int    // Starting position in original text
 }
 
-// Parser defines the interface for extracting JSON from text
-type Parser interface {
+// CandidateParser defines the interface for extracting JSON from text.
+// It was named Parser before that name went to the parser type itself;
+// see CHANGELOG.md.
+type CandidateParser interface {
 	// Parse extracts potential JSON candidates from input text
 	Parse(input string) ([]JSONCandidate, error)
 }
@@ -115,12 +196,15 @@ type Extractor struct {
 
 // StreamingOptions configures streaming behavior
 type StreamingOptions struct {
-	AllowIncompleteJSON bool
+	AllowIncompleteJSON  bool
 	TrackCompletionState bool
 }
 
 // ParseOptions configures parsing behavior
 type ParseOptions struct {
-	Streaming StreamingOptions
-	Strict    bool // If true, only accept exact JSON matches
+	Streaming   StreamingOptions
+	Strict      bool             // If true, only accept exact JSON matches
+	Required    RequiredPolicy   // Which struct fields must be present
+	Constraints ConstraintPolicy // What to do with constraint violations
+	MaxScore    int              // Reject candidates scoring above this; 0 means no limit
 }
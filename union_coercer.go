@@ -0,0 +1,337 @@
+package sap
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// unionDef describes the concrete types that may be stored in an
+// interface type.
+type unionDef struct {
+	variants      []reflect.Type      // in registration order
+	discriminator string              // key naming the variant, "" for structural matching
+	tags          []string            // discriminator value for each variant, nil to use type names
+	suffix        string              // last word of the interface's name, e.g. "Tool"
+	aliases       map[string][]string // extra discriminator values, by variant tag
+}
+
+var (
+	unionMu       sync.RWMutex
+	unionRegistry = make(map[reflect.Type]*unionDef)
+)
+
+// RegisterUnion declares the concrete types that may be stored in the
+// interface type T. Fields of type T are then coerced to whichever variant
+// fits the input best:
+//
+//	type Shape interface{ Area() float64 }
+//
+//	sap.RegisterUnion[Shape](Circle{}, Square{})
+//
+// Each variant is tried in turn and scored like any other parse, with an
+// extra penalty for input keys the variant has no field for and for
+// variant fields the input doesn't mention. The lowest score wins; a tie
+// between variants is an error. Pass pointers (&Circle{}) to store
+// pointers in the interface. Registering the same type again replaces its
+// previous variants.
+func RegisterUnion[T any](variants ...T) {
+	unionType := reflect.TypeOf((*T)(nil)).Elem()
+	if unionType.Kind() != reflect.Interface {
+		panic(fmt.Sprintf("sap: RegisterUnion requires an interface type, got %v", unionType))
+	}
+
+	def := &unionDef{suffix: lastWord(unionType.Name())}
+	for _, v := range variants {
+		rv := reflect.ValueOf(v)
+		if !rv.IsValid() {
+			panic(fmt.Sprintf("sap: RegisterUnion[%v] called with a nil variant", unionType))
+		}
+		def.variants = append(def.variants, rv.Type())
+	}
+
+	unionMu.Lock()
+	unionRegistry[unionType] = def
+	unionMu.Unlock()
+}
+
+// RegisterTaggedUnion declares a discriminated union: the interface type T
+// holds one of the given variants, selected by the value of the
+// discriminator key in the input object.
+//
+//	sap.RegisterTaggedUnion[Tool]("type", map[string]Tool{
+//	    "search":     SearchTool{},
+//	    "calculator": CalculatorTool{},
+//	})
+//
+// The discriminator value is matched like an enum, so "Search", "search_tool"
+// and "srch" all select SearchTool. Objects without the discriminator key
+// fall back to structural matching as in RegisterUnion.
+func RegisterTaggedUnion[T any](discriminator string, variants map[string]T) {
+	unionType := reflect.TypeOf((*T)(nil)).Elem()
+	if unionType.Kind() != reflect.Interface {
+		panic(fmt.Sprintf("sap: RegisterTaggedUnion requires an interface type, got %v", unionType))
+	}
+
+	tags := make([]string, 0, len(variants))
+	for tag := range variants {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	def := &unionDef{discriminator: discriminator, tags: tags}
+	for _, tag := range tags {
+		rv := reflect.ValueOf(variants[tag])
+		if !rv.IsValid() {
+			panic(fmt.Sprintf("sap: RegisterTaggedUnion[%v] called with a nil variant for %q", unionType, tag))
+		}
+		def.variants = append(def.variants, rv.Type())
+	}
+
+	unionMu.Lock()
+	unionRegistry[unionType] = def
+	unionMu.Unlock()
+}
+
+// withDiscriminator returns a copy of d keyed on the given discriminator,
+// as declared by a `gsap:"discriminator=..."` field tag.
+func (d *unionDef) withDiscriminator(discriminator string) *unionDef {
+	out := *d
+	out.discriminator = discriminator
+	return &out
+}
+
+// withOptions returns d as configured by a field's tag: keyed on its
+// discriminator, with values from its alias= option also selecting
+// variants, e.g. `gsap:"discriminator=type,alias=srch:SearchTool"`.
+func (d *unionDef) withOptions(opts fieldOptions) *unionDef {
+	if opts.discriminator == "" {
+		return d
+	}
+	out := d.withDiscriminator(opts.discriminator)
+	out.aliases = opts.aliases
+	return out
+}
+
+// tagCandidates returns the discriminator values that select each variant.
+// Variants registered without explicit tags are selected by their type
+// name, e.g. "SearchTool" or "search_tool", and by that name without the
+// interface's last word, e.g. "Search" for a Tool. Aliases from the
+// field's tag add to either.
+func (d *unionDef) tagCandidates() []enumCandidate {
+	var out []enumCandidate
+	add := func(text string, member int) {
+		for _, c := range out {
+			if c.member == member && c.text == text {
+				return
+			}
+		}
+		out = append(out, enumCandidate{text: text, member: member})
+	}
+
+	for i, variant := range d.variants {
+		var names []string
+		if d.tags != nil {
+			names = []string{d.tags[i]}
+		} else {
+			for variant.Kind() == reflect.Ptr {
+				variant = variant.Elem()
+			}
+			name := variant.Name()
+			names = []string{name, toSnakeCase(name)}
+			if stem := strings.TrimSuffix(name, d.suffix); d.suffix != "" && stem != name && stem != "" {
+				names = append(names, stem, toSnakeCase(stem))
+			}
+		}
+		for _, name := range names {
+			add(name, i)
+		}
+		for _, name := range names {
+			for _, alias := range d.aliases[name] {
+				add(alias, i)
+			}
+		}
+	}
+	return out
+}
+
+// lastWord returns the last word of a Go identifier, e.g. "Tool" for
+// "AgentTool", or "" if it is a single lowercase word.
+func lastWord(name string) string {
+	runes := []rune(name)
+	for i := len(runes) - 1; i > 0; i-- {
+		if unicode.IsUpper(runes[i]) {
+			return string(runes[i:])
+		}
+	}
+	return ""
+}
+
+// tagNames returns the canonical discriminator value of each variant.
+func (d *unionDef) tagNames() []string {
+	var names []string
+	seen := make(map[int]bool)
+	for _, c := range d.tagCandidates() {
+		if !seen[c.member] {
+			seen[c.member] = true
+			names = append(names, c.text)
+		}
+	}
+	return names
+}
+
+// toSnakeCase converts a Go identifier like "SearchTool" to "search_tool".
+func toSnakeCase(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// lookupUnion returns the registered definition for unionType, or nil.
+func lookupUnion(unionType reflect.Type) *unionDef {
+	unionMu.RLock()
+	defer unionMu.RUnlock()
+	return unionRegistry[unionType]
+}
+
+// coerceToUnion converts value to the variant of the union selected by its
+// discriminator, or failing that, to the variant that fits best.
+func (c *TypeCoercer) coerceToUnion(value interface{}, targetType reflect.Type, def *unionDef, score *Score) (interface{}, error) {
+	if def.discriminator != "" {
+		if mapVal, ok := value.(map[string]interface{}); ok {
+			if tag, found := discriminatorValue(mapVal, def.discriminator); found {
+				i, ok := matchEnum(tag, def.tagCandidates(), score)
+				if !ok {
+					return nil, fmt.Errorf("cannot convert to %v: unknown %s %q (want one of %s)",
+						targetType, def.discriminator, tag, strings.Join(def.tagNames(), ", "))
+				}
+				return c.coerceValue(value, def.variants[i], score)
+			}
+		}
+	}
+
+	var (
+		best      interface{}
+		bestScore *Score
+		bestErrs  []FieldError
+		bestSeen  []presenceEntry
+		tied      []reflect.Type
+		failures  []string
+	)
+
+	for _, variant := range def.variants {
+		// Keep field errors and presence of variants that lose out of the
+		// result
+		mark, seenMark := len(c.errors), len(c.presence)
+		variantScore := &Score{flags: make(map[string]int)}
+		result, err := c.coerceValue(value, variant, variantScore)
+		variantErrs := append([]FieldError(nil), c.errors[mark:]...)
+		variantSeen := append([]presenceEntry(nil), c.presence[seenMark:]...)
+		c.errors = c.errors[:mark]
+		c.presence = c.presence[:seenMark]
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%v: %v", variant, err))
+			continue
+		}
+		addStructFitPenalty(value, variant, variantScore)
+
+		// Prefer the variant with fewer failed fields, then the lower score
+		switch {
+		case bestScore == nil || len(variantErrs) < len(bestErrs) ||
+			(len(variantErrs) == len(bestErrs) && variantScore.Less(bestScore)):
+			best = result
+			bestScore = variantScore
+			bestErrs = variantErrs
+			bestSeen = variantSeen
+			tied = []reflect.Type{variant}
+		case len(variantErrs) == len(bestErrs) && variantScore.Total() == bestScore.Total():
+			tied = append(tied, variant)
+		}
+	}
+
+	if bestScore == nil {
+		return nil, fmt.Errorf("cannot convert to %v: no variant matched (%s)",
+			targetType, strings.Join(failures, "; "))
+	}
+	if len(tied) > 1 {
+		names := make([]string, len(tied))
+		for i, t := range tied {
+			names[i] = t.String()
+		}
+		return nil, fmt.Errorf("%w %v: %s fit equally well", ErrAmbiguous, targetType, strings.Join(names, ", "))
+	}
+
+	c.errors = append(c.errors, bestErrs...)
+	c.presence = append(c.presence, bestSeen...)
+	score.merge(bestScore)
+	return best, nil
+}
+
+// discriminatorValue returns the string value of the discriminator key in
+// mapVal, matching the key case-insensitively if needed.
+func discriminatorValue(mapVal map[string]interface{}, key string) (string, bool) {
+	raw, ok := mapVal[key]
+	if !ok {
+		for k, v := range mapVal {
+			if strings.EqualFold(k, key) {
+				raw, ok = v, true
+				break
+			}
+		}
+	}
+	if !ok || raw == nil {
+		return "", false
+	}
+	tag, err := coerceValueToString(raw)
+	if err != nil || isNullString(tag) {
+		return "", false
+	}
+	return tag, true
+}
+
+// addStructFitPenalty penalizes a struct variant for keys in value it has
+// no field for and for fields value doesn't provide. Without it, a variant
+// whose fields are all absent would coerce cleanly from any object.
+func addStructFitPenalty(value interface{}, variant reflect.Type, score *Score) {
+	mapVal, ok := value.(map[string]interface{})
+	if !ok {
+		return
+	}
+	for variant.Kind() == reflect.Ptr {
+		variant = variant.Elem()
+	}
+	if variant.Kind() != reflect.Struct {
+		return
+	}
+
+	used := make(map[string]bool)
+	missing := 0
+	plan := structPlanFor(variant)
+	keys := plan.matchKeys(mapVal)
+	for i := range plan.fields {
+		if key, _ := keys.find(i); key != "" {
+			used[key] = true
+		} else {
+			missing++
+		}
+	}
+
+	if unmatched := len(mapVal) - len(used); unmatched > 0 {
+		score.AddFlag(FlagUnionUnmatchedKeys, 2*unmatched)
+	}
+	if missing > 0 {
+		score.AddFlag(FlagUnionMissingFields, missing)
+	}
+}
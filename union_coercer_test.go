@@ -0,0 +1,334 @@
+package sap
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testShape interface {
+	Area() float64
+}
+
+type testCircle struct {
+	Radius float64 `json:"radius"`
+}
+
+func (c testCircle) Area() float64 { return 3 * c.Radius * c.Radius }
+
+type testSquare struct {
+	Side float64 `json:"side"`
+}
+
+func (s testSquare) Area() float64 { return s.Side * s.Side }
+
+type testRect struct {
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+func (r *testRect) Area() float64 { return r.Width * r.Height }
+
+type testDrawing struct {
+	Title  string      `json:"title"`
+	Main   testShape   `json:"main"`
+	Others []testShape `json:"others"`
+}
+
+func init() {
+	RegisterUnion[testShape](testCircle{}, testSquare{}, &testRect{})
+}
+
+func TestParseUnionPicksBestVariant(t *testing.T) {
+	input := `{
+		"title": "shapes",
+		"main": {"radius": "2"},
+		"others": [{"side": 3}, {"width": 2, "height": 5}, {"Radius": 1}]
+	}`
+
+	d, score, err := ParseWithScore[testDrawing](input)
+	if err != nil {
+		t.Fatalf("ParseWithScore failed: %v", err)
+	}
+
+	circle, ok := d.Main.(testCircle)
+	if !ok || circle.Radius != 2 {
+		t.Fatalf("Main = %#v, want testCircle{Radius: 2}", d.Main)
+	}
+	if _, ok := score.Flags()[FlagStringToFloat]; !ok {
+		t.Errorf("expected variant flags to be merged, got %v", score.Flags())
+	}
+
+	if len(d.Others) != 3 {
+		t.Fatalf("expected 3 others, got %d", len(d.Others))
+	}
+	if sq, ok := d.Others[0].(testSquare); !ok || sq.Side != 3 {
+		t.Errorf("Others[0] = %#v, want testSquare{Side: 3}", d.Others[0])
+	}
+	if r, ok := d.Others[1].(*testRect); !ok || r.Width != 2 || r.Height != 5 {
+		t.Errorf("Others[1] = %#v, want &testRect{2, 5}", d.Others[1])
+	}
+	if c, ok := d.Others[2].(testCircle); !ok || c.Radius != 1 {
+		t.Errorf("Others[2] = %#v, want testCircle{Radius: 1}", d.Others[2])
+	}
+}
+
+func TestParseUnionPartialFieldsPreferFewerMissing(t *testing.T) {
+	shape, err := Parse[testShape](`{"width": 4}`)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if r, ok := shape.(*testRect); !ok || r.Width != 4 {
+		t.Errorf("got %#v, want &testRect{Width: 4}", shape)
+	}
+}
+
+func TestParseUnionPresenceFromWinningVariant(t *testing.T) {
+	_, res, err := ParseDetailed[testDrawing](`{"title": "one", "main": {"width": 2, "height": 3}}`)
+	if err != nil {
+		t.Fatalf("ParseDetailed failed: %v", err)
+	}
+	want := map[string]FieldPresence{
+		"title":       FieldPopulated,
+		"main":        FieldPopulated,
+		"main.width":  FieldPopulated,
+		"main.height": FieldPopulated,
+		"others":      FieldMissing,
+	}
+	if !reflect.DeepEqual(res.Presence, want) {
+		t.Errorf("Presence = %v, want %v", res.Presence, want)
+	}
+}
+
+func TestParseUnionAmbiguous(t *testing.T) {
+	_, err := Parse[testShape](`{"radius": 1, "side": 2}`)
+	if err == nil {
+		t.Fatal("expected ambiguity error")
+	}
+	if !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("expected ambiguous error, got %v", err)
+	}
+}
+
+func TestParseUnionNoVariantMatches(t *testing.T) {
+	_, err := Parse[testShape](`["not", "a", "shape"]`)
+	if err == nil {
+		t.Fatal("expected error when no variant matches")
+	}
+	if !strings.Contains(err.Error(), "no variant matched") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestRegisterUnionRequiresInterface(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for non-interface union type")
+		}
+	}()
+	RegisterUnion[testCircle](testCircle{})
+}
+
+func TestUnregisteredInterfaceKeepsRawValue(t *testing.T) {
+	type holder struct {
+		Data interface{} `json:"data"`
+	}
+	h, err := Parse[holder](`{"data": {"a": 1}}`)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if _, ok := h.Data.(map[string]interface{}); !ok {
+		t.Errorf("Data = %#v, want raw map", h.Data)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Discriminated unions
+// ---------------------------------------------------------------------------
+
+type testTool interface {
+	toolName() string
+}
+
+type testSearchTool struct {
+	Type  string `json:"type"`
+	Query string `json:"query"`
+}
+
+func (testSearchTool) toolName() string { return "search" }
+
+type testCalculatorTool struct {
+	Type       string `json:"type"`
+	Expression string `json:"expression"`
+}
+
+func (testCalculatorTool) toolName() string { return "calculator" }
+
+type testToolCall struct {
+	Tool testTool `json:"tool"`
+}
+
+type testAnimal interface {
+	sound() string
+}
+
+type testDog struct {
+	Name string `json:"name"`
+}
+
+func (testDog) sound() string { return "woof" }
+
+type testCat struct {
+	Name string `json:"name"`
+}
+
+func (testCat) sound() string { return "meow" }
+
+type testPets struct {
+	Pets []testAnimal `json:"pets" gsap:"discriminator=kind"`
+}
+
+func init() {
+	RegisterTaggedUnion[testTool]("type", map[string]testTool{
+		"search":     testSearchTool{},
+		"calculator": testCalculatorTool{},
+	})
+	RegisterUnion[testAnimal](testDog{}, testCat{})
+}
+
+func TestParseDiscriminatedUnion(t *testing.T) {
+	tests := []struct {
+		name     string
+		tag      string
+		wantTool string
+		wantFlag string
+	}{
+		{"exact", "search", "search", ""},
+		{"capitalized", "Search", "search", FlagEnumCaseInsensitive},
+		{"suffixed", "search_tool", "search", FlagEnumWordMatch},
+		{"abbreviated", "srch", "search", FlagEnumFuzzyMatch},
+		{"other variant", "CALCULATOR", "calculator", FlagEnumCaseInsensitive},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := `{"tool": {"type": "` + tt.tag + `", "query": "go generics", "expression": "1+1"}}`
+			call, score, err := ParseWithScore[testToolCall](input)
+			if err != nil {
+				t.Fatalf("ParseWithScore failed: %v", err)
+			}
+			if call.Tool == nil || call.Tool.toolName() != tt.wantTool {
+				t.Fatalf("Tool = %#v, want %s tool", call.Tool, tt.wantTool)
+			}
+			if tt.wantFlag != "" {
+				if _, ok := score.Flags()[tt.wantFlag]; !ok {
+					t.Errorf("expected %s flag, got %v", tt.wantFlag, score.Flags())
+				}
+			}
+		})
+	}
+}
+
+func TestParseDiscriminatedUnionFallsBackToStructure(t *testing.T) {
+	call, err := Parse[testToolCall](`{"tool": {"expression": "2*3"}}`)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	calc, ok := call.Tool.(testCalculatorTool)
+	if !ok || calc.Expression != "2*3" {
+		t.Errorf("Tool = %#v, want calculator", call.Tool)
+	}
+}
+
+func TestParseDiscriminatedUnionUnknownTag(t *testing.T) {
+	_, err := Parse[testTool](`{"type": "weather forecast lookup", "query": "rain"}`)
+	if err == nil {
+		t.Fatal("expected error for unknown discriminator")
+	}
+	if !strings.Contains(err.Error(), "calculator, search") {
+		t.Errorf("error should list valid tags, got %v", err)
+	}
+}
+
+func TestParseDiscriminatorTag(t *testing.T) {
+	// Both variants have the same fields, so only the discriminator can
+	// tell them apart.
+	pets, err := Parse[testPets](`{"pets": [{"kind": "tstCat", "name": "Tom"}, {"Kind": "TestDog", "name": "Rex"}]}`)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(pets.Pets) != 2 {
+		t.Fatalf("expected 2 pets, got %d", len(pets.Pets))
+	}
+	if c, ok := pets.Pets[0].(testCat); !ok || c.Name != "Tom" {
+		t.Errorf("Pets[0] = %#v, want testCat{Tom}", pets.Pets[0])
+	}
+	if d, ok := pets.Pets[1].(testDog); !ok || d.Name != "Rex" {
+		t.Errorf("Pets[1] = %#v, want testDog{Rex}", pets.Pets[1])
+	}
+}
+
+type agentTool interface {
+	run() string
+}
+
+type searchTool struct {
+	Query string `json:"query"`
+}
+
+func (searchTool) run() string { return "search" }
+
+type calculatorTool struct {
+	Expression string `json:"expression"`
+}
+
+func (calculatorTool) run() string { return "calculator" }
+
+type agentStep struct {
+	Tool agentTool `json:"tool" gsap:"discriminator=type,alias=lookup:searchTool|math:calculator"`
+}
+
+func init() {
+	RegisterUnion[agentTool](searchTool{}, calculatorTool{})
+}
+
+func TestParseDiscriminatorTagNames(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want string
+	}{
+		{"searchTool", "search"},
+		{"search_tool", "search"},
+		{"Search", "search"},
+		{"srch", "search"},
+		{"calculator", "calculator"},
+		{"lookup", "search"},
+		{"Math", "calculator"},
+	}
+	for _, tt := range tests {
+		// Both variants get every key, so only the discriminator decides
+		input := `{"tool": {"type": "` + tt.tag + `", "query": "go", "expression": "1+1"}}`
+		step, err := Parse[agentStep](input)
+		if err != nil {
+			t.Errorf("%s: Parse failed: %v", tt.tag, err)
+			continue
+		}
+		if step.Tool == nil || step.Tool.run() != tt.want {
+			t.Errorf("%s: Tool = %#v, want %s", tt.tag, step.Tool, tt.want)
+		}
+	}
+}
+
+func TestToSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"SearchTool": "search_tool",
+		"HTTPServer": "http_server",
+		"userID":     "user_id",
+		"plain":      "plain",
+	}
+	for in, want := range tests {
+		if got := toSnakeCase(in); got != want {
+			t.Errorf("toSnakeCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
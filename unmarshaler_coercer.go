@@ -0,0 +1,111 @@
+package sap
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// isUnmarshaler reports whether a pointer to t implements json.Unmarshaler
+// or encoding.TextUnmarshaler, so t decodes itself rather than by kind.
+// time.Time is excluded because it has its own, more lenient coercion.
+func isUnmarshaler(t reflect.Type) bool {
+	if t == timeType || t.Kind() == reflect.Ptr || t.Kind() == reflect.Interface {
+		return false
+	}
+	ptr := reflect.PointerTo(t)
+	return ptr.Implements(jsonUnmarshalerType) || ptr.Implements(textUnmarshalerType)
+}
+
+// coerceWithUnmarshaler converts value to a type that implements
+// json.Unmarshaler or encoding.TextUnmarshaler, such as uuid.UUID or
+// decimal.Decimal.
+//
+// The value is first re-marshaled to JSON for UnmarshalJSON, then given
+// in string form to UnmarshalText. If both fail on a string, they are
+// retried with markdown and trailing units stripped, e.g. "**12.50 USD**"
+// becomes "12.50".
+func (c *TypeCoercer) coerceWithUnmarshaler(value interface{}, targetType reflect.Type, score *Score) (interface{}, error) {
+	result, err := unmarshalInto(value, targetType)
+	if err == nil {
+		return result, nil
+	}
+
+	if s, ok := value.(string); ok {
+		stripped, hadMarkdown := stripMarkdown(strings.TrimSpace(s))
+		if hadMarkdown {
+			if result, retryErr := unmarshalInto(stripped, targetType); retryErr == nil {
+				score.AddFlag(FlagMarkdownStripped, 1)
+				return result, nil
+			}
+		}
+		if m := reTrailingUnits.FindStringSubmatch(strings.TrimSpace(stripped)); m != nil {
+			if result, retryErr := unmarshalInto(m[1], targetType); retryErr == nil {
+				if hadMarkdown {
+					score.AddFlag(FlagMarkdownStripped, 1)
+				}
+				score.AddFlag(FlagUnitStripped, 1)
+				return result, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("cannot convert %T to %v: %w", value, targetType, err)
+}
+
+// unmarshalInto decodes value into a new targetType using its
+// UnmarshalJSON or UnmarshalText method.
+func unmarshalInto(value interface{}, targetType reflect.Type) (interface{}, error) {
+	ptr := reflect.New(targetType)
+
+	var jsonErr error
+	if u, ok := ptr.Interface().(json.Unmarshaler); ok {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		if jsonErr = u.UnmarshalJSON(data); jsonErr == nil {
+			return ptr.Elem().Interface(), nil
+		}
+
+		// A quoted number or object, e.g. "12.50" for a decimal type
+		if s, ok := value.(string); ok && json.Valid([]byte(s)) {
+			ptr = reflect.New(targetType)
+			if err := ptr.Interface().(json.Unmarshaler).UnmarshalJSON([]byte(s)); err == nil {
+				return ptr.Elem().Interface(), nil
+			}
+		}
+	}
+
+	ptr = reflect.New(targetType)
+	if u, ok := ptr.Interface().(encoding.TextUnmarshaler); ok {
+		var text string
+		switch v := value.(type) {
+		case string:
+			text = v
+		case float64:
+			text = strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			text = strconv.FormatBool(v)
+		default:
+			if jsonErr != nil {
+				return nil, jsonErr
+			}
+			return nil, fmt.Errorf("cannot use %T as text", value)
+		}
+		if err := u.UnmarshalText([]byte(text)); err != nil {
+			return nil, err
+		}
+		return ptr.Elem().Interface(), nil
+	}
+
+	return nil, jsonErr
+}
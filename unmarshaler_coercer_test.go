@@ -0,0 +1,127 @@
+package sap
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testMoney decodes a JSON number of dollars into cents.
+type testMoney struct {
+	Cents int64
+}
+
+func (m *testMoney) UnmarshalJSON(data []byte) error {
+	var dollars float64
+	if err := json.Unmarshal(data, &dollars); err != nil {
+		return fmt.Errorf("money must be a number: %w", err)
+	}
+	m.Cents = int64(math.Round(dollars * 100))
+	return nil
+}
+
+// testCurrency is an ISO 4217 code. As an array it used to be coerced
+// element by element.
+type testCurrency [3]byte
+
+func (c *testCurrency) UnmarshalText(text []byte) error {
+	s := strings.ToUpper(strings.TrimSpace(string(text)))
+	if len(s) != 3 {
+		return errors.New("currency must be a 3-letter code")
+	}
+	copy(c[:], s)
+	return nil
+}
+
+// testID is a 16-byte identifier written as 32 hex digits.
+type testID [16]byte
+
+func (id *testID) UnmarshalText(text []byte) error {
+	b, err := hex.DecodeString(strings.ReplaceAll(string(text), "-", ""))
+	if err != nil || len(b) != 16 {
+		return errors.New("invalid id")
+	}
+	copy(id[:], b)
+	return nil
+}
+
+type testInvoice struct {
+	ID       testID       `json:"id"`
+	Total    testMoney    `json:"total"`
+	Tax      *testMoney   `json:"tax"`
+	Currency testCurrency `json:"currency"`
+	Issued   time.Time    `json:"issued"`
+}
+
+func TestParseUnmarshalerTypes(t *testing.T) {
+	input := `{
+		"id": "123e4567-e89b-12d3-a456-426614174000",
+		"total": 12.5,
+		"tax": "1.25",
+		"currency": "usd",
+		"issued": "2024-01-15"
+	}`
+	inv, err := Parse[testInvoice](input)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if got := hex.EncodeToString(inv.ID[:]); got != "123e4567e89b12d3a456426614174000" {
+		t.Errorf("ID = %s", got)
+	}
+	if inv.Total.Cents != 1250 {
+		t.Errorf("Total = %d cents, want 1250", inv.Total.Cents)
+	}
+	if inv.Tax == nil || inv.Tax.Cents != 125 {
+		t.Errorf("Tax = %+v, want 125 cents", inv.Tax)
+	}
+	if string(inv.Currency[:]) != "USD" {
+		t.Errorf("Currency = %q, want USD", inv.Currency[:])
+	}
+	if inv.Issued.Year() != 2024 {
+		t.Errorf("Issued = %v, want the lenient time parsing", inv.Issued)
+	}
+}
+
+func TestParseUnmarshalerLenientRetry(t *testing.T) {
+	tests := []struct {
+		input     string
+		wantCents int64
+		wantFlags []string
+	}{
+		{`{"total": "**19.99**"}`, 1999, []string{FlagMarkdownStripped}},
+		{`{"total": "19.99 dollars"}`, 1999, []string{FlagUnitStripped}},
+		{`{"total": "_5 USD_"}`, 500, []string{FlagMarkdownStripped, FlagUnitStripped}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			inv, score, err := ParseWithScore[testInvoice](tt.input)
+			if err != nil {
+				t.Fatalf("ParseWithScore failed: %v", err)
+			}
+			if inv.Total.Cents != tt.wantCents {
+				t.Errorf("Total = %d cents, want %d", inv.Total.Cents, tt.wantCents)
+			}
+			for _, flag := range tt.wantFlags {
+				if _, ok := score.Flags()[flag]; !ok {
+					t.Errorf("expected %s flag, got %v", flag, score.Flags())
+				}
+			}
+		})
+	}
+}
+
+func TestParseUnmarshalerError(t *testing.T) {
+	_, err := Parse[testInvoice](`{"currency": "dollars"}`)
+	var perr *ParseError
+	if !errors.As(err, &perr) || perr.Errors[0].Path != "currency" {
+		t.Fatalf("expected a field error for currency, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "currency must be a 3-letter code") {
+		t.Errorf("expected the UnmarshalText error, got %v", err)
+	}
+}
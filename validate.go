@@ -0,0 +1,84 @@
+package sap
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Validator is implemented by types with invariants that span fields, such
+// as an end date after a start date. After a struct is coerced, its
+// Validate method is called, whether it has a value or pointer receiver:
+//
+//	func (b Booking) Validate() error {
+//	    if b.End.Before(b.Start) {
+//	        return &sap.FieldError{Path: "end", Cause: errors.New("end is before start")}
+//	    }
+//	    return nil
+//	}
+//
+// Returning a *FieldError, or a *ParseError holding several, reports the
+// failure at a path relative to the struct; any other error is reported at
+// the struct itself. Failures match ErrValidation and count against the
+// candidate when choosing between several JSON blocks in a response.
+type Validator interface {
+	Validate() error
+}
+
+// validatorType is the reflect.Type of Validator.
+var validatorType = reflect.TypeOf((*Validator)(nil)).Elem()
+
+// validate calls the Validate method of the coerced struct v, if it has
+// one, and records its failures at the current path.
+func (c *TypeCoercer) validate(v reflect.Value, score *Score) {
+	var validator Validator
+	switch {
+	case v.Type().Implements(validatorType):
+		validator = v.Interface().(Validator)
+	case v.CanAddr() && v.Addr().Type().Implements(validatorType):
+		validator = v.Addr().Interface().(Validator)
+	default:
+		return
+	}
+	c.reportValidation(validator.Validate(), v.Type(), score)
+}
+
+// reportValidation records the failures in err, returned by the Validate
+// method of a struct of type target, at the current path.
+func (c *TypeCoercer) reportValidation(err error, target reflect.Type, score *Score) {
+	if err == nil {
+		return
+	}
+
+	var failures []FieldError
+	var perr *ParseError
+	var fe *FieldError
+	switch {
+	case errors.As(err, &perr):
+		failures = perr.Errors
+	case errors.As(err, &fe):
+		failures = []FieldError{*fe}
+	default:
+		failures = []FieldError{{Cause: err}}
+	}
+
+	base := c.currentPath()
+	for _, f := range failures {
+		if f.Target == nil {
+			f.Target = target
+		}
+		f.Path = joinPath(base, f.Path)
+		f.Cause = fmt.Errorf("%w: %w", ErrValidation, f.Cause)
+		c.errors = append(c.errors, f)
+		score.AddFlag(FlagValidationFailed, 5)
+	}
+}
+
+// joinPath appends a relative path like "end" or "[2].age" to base.
+func joinPath(base, rel string) string {
+	if base == "" || rel == "" || strings.HasPrefix(rel, "[") {
+		return base + rel
+	}
+	return base + "." + rel
+}
@@ -0,0 +1,150 @@
+package sap
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testBooking struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+func (b testBooking) Validate() error {
+	if b.End.Before(b.Start) {
+		return &FieldError{Path: "end", Raw: b.End, Cause: errors.New("end is before start")}
+	}
+	return nil
+}
+
+type testAllocation struct {
+	Name   string `json:"name"`
+	Shares []struct {
+		Team    string `json:"team"`
+		Percent int    `json:"percent"`
+	} `json:"shares"`
+}
+
+func (a *testAllocation) Validate() error {
+	total := 0
+	for _, s := range a.Shares {
+		total += s.Percent
+	}
+	if total != 100 {
+		return fmt.Errorf("percentages sum to %d, not 100", total)
+	}
+	return nil
+}
+
+type testTrip struct {
+	Title    string          `json:"title"`
+	Bookings []testBooking   `json:"bookings"`
+	Budget   *testAllocation `json:"budget"`
+}
+
+func TestParseValidateNested(t *testing.T) {
+	input := `{
+		"title": "offsite",
+		"bookings": [
+			{"start": "2024-05-01", "end": "2024-05-03"},
+			{"start": "2024-06-10", "end": "2024-06-01"}
+		],
+		"budget": {"name": "travel", "shares": [{"team": "a", "percent": 60}, {"team": "b", "percent": 30}]}
+	}`
+	trip, score, err := ParseWithScore[testTrip](input)
+
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected *ParseError, got %v", err)
+	}
+	if !errors.Is(err, ErrValidation) {
+		t.Errorf("expected errors.Is(err, ErrValidation)")
+	}
+
+	var got []string
+	for _, fe := range perr.Errors {
+		got = append(got, fe.Error())
+	}
+	want := []string{
+		"bookings[1].end: validation failed: end is before start",
+		"budget: validation failed: percentages sum to 90, not 100",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("errors = %q, want %q", got, want)
+	}
+
+	// Validation failures keep the parsed values
+	if len(trip.Bookings) != 2 || trip.Budget == nil || len(trip.Budget.Shares) != 2 {
+		t.Errorf("expected the parsed values to be kept, got %+v", trip)
+	}
+	if _, ok := score.Flags()[FlagValidationFailed]; !ok {
+		t.Errorf("expected %s flag, got %v", FlagValidationFailed, score.Flags())
+	}
+}
+
+func TestParseValidatePrefersValidCandidate(t *testing.T) {
+	input := "Draft: {\"start\": \"2024-05-03\", \"end\": \"2024-05-01\"}\n" +
+		"Fixed: {\"start\": \"2024-05-01\", \"end\": \"2024-05-03\"}"
+	b, err := Parse[testBooking](input)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if b.Start.Day() != 1 || b.End.Day() != 3 {
+		t.Errorf("expected the valid candidate, got %+v", b)
+	}
+}
+
+func TestParseValidateSkippedAfterFieldErrors(t *testing.T) {
+	// A share failed to coerce, so Validate is not called on the
+	// incomplete allocation
+	input := `{"name": "travel", "shares": [{"team": "a", "percent": "most"}]}`
+	_, err := Parse[testAllocation](input)
+	if err == nil {
+		t.Fatal("expected a coercion error")
+	}
+	if errors.Is(err, ErrValidation) {
+		t.Errorf("expected Validate to be skipped, got %v", err)
+	}
+}
+
+type testCustomer struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+func (c testCustomer) Validate() error {
+	if c.Name == "" && c.Email == "" {
+		return errors.New("need name or email")
+	}
+	return nil
+}
+
+type testShippingAddress struct {
+	City string `json:"city" gsap:"required"`
+}
+
+type testCustomerOrder struct {
+	ID       string              `json:"id"`
+	Customer testCustomer        `json:"customer"`
+	Shipping testShippingAddress `json:"shipping"`
+}
+
+func TestParseValidateSkipsAbsentStruct(t *testing.T) {
+	// Only the absent struct's required fields are checked; its Validate
+	// method doesn't run on a value the input never sent
+	_, res, err := ParseDetailed[testCustomerOrder](`{"id": "A1"}`)
+	var perr *ParseError
+	if !errors.As(err, &perr) || len(perr.Errors) != 1 || perr.Errors[0].Path != "shipping.city" {
+		t.Fatalf("expected only shipping.city to be missing, got %v", err)
+	}
+	if res.Presence["customer"] != FieldMissing || res.Presence["customer.name"] != FieldMissing {
+		t.Errorf("unexpected presence %v", res.Presence)
+	}
+
+	if _, err := Parse[testCustomerOrder](`{"id": "A1", "customer": {}, "shipping": {"city": "Oslo"}}`); !errors.Is(err, ErrValidation) {
+		t.Errorf("expected Validate to run on a customer in the input, got %v", err)
+	}
+}